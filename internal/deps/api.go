@@ -1,26 +1,44 @@
 package deps
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/package-url/packageurl-go"
 )
 
 const (
-	depsDevAPIBase = "https://api.deps.dev/v3"
-	requestTimeout = 30 * time.Second
+	depsDevAPIBase  = "https://api.deps.dev/v3"
+	depsDevBatchURL = "https://api.deps.dev/v3alpha/versionbatch"
+	requestTimeout  = 30 * time.Second
 )
 
 // Version represents a package version with publication date
 type Version struct {
 	Version     string `json:"version" bson:"version"`
 	PublishedAt string `json:"publishedAt" bson:"publishedAt"`
+	// Yanked marks releases withdrawn by their publisher, e.g. on PyPI or crates.io
+	Yanked bool `json:"yanked,omitempty" bson:"yanked,omitempty"`
+	// Retracted marks releases retracted by a later release, e.g. with a Go retract directive,
+	// or retired on Hex or pub
+	Retracted bool `json:"retracted,omitempty" bson:"retracted,omitempty"`
+	// Deprecated marks releases their publisher advises against, e.g. with npm deprecate
+	Deprecated bool `json:"deprecated,omitempty" bson:"deprecated,omitempty"`
+}
+
+// Withdrawn reports whether the version was yanked, retracted or deprecated upstream
+func (v *Version) Withdrawn() bool {
+	return v.Yanked || v.Retracted || v.Deprecated
 }
 
 // Time parses the PublishedAt field as RFC3339 time
@@ -31,64 +49,306 @@ func (v *Version) Time() (time.Time, error) {
 	return time.Parse(time.RFC3339, v.PublishedAt)
 }
 
+// MetadataSource is the APIResponse metadata key naming the source that served the versions.
+// deps.dev marks its responses so that version details are only requested for packages it
+// knows, and never for packages resolved from a private registry.
+const MetadataSource = "source"
+
+// MetadataUndated is the APIResponse metadata key that sources set to "true" if their versions
+// carry no publication dates by design, such as Debian package indexes. The libyear of such
+// packages is unknown rather than zero.
+const MetadataUndated = "undated"
+
 // APIResponse represents the response from the deps.dev API
 type APIResponse struct {
 	Versions []VersionsAPIResponse `json:"versions"`
+	// Metadata holds optional, source-specific package information
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // VersionsAPIResponse represents a version entry in the API response
 type VersionsAPIResponse struct {
-	Version     Version `json:"versionKey"`
-	PublishedAt string  `json:"publishedAt" bson:"publishedAt"`
+	Version      Version `json:"versionKey"`
+	PublishedAt  string  `json:"publishedAt" bson:"publishedAt"`
+	IsDeprecated bool    `json:"isDeprecated,omitempty" bson:"isDeprecated,omitempty"`
 }
 
 // Client provides access to the deps.dev API
 type Client struct {
 	httpClient *http.Client
 	logger     *slog.Logger
+	cache      *Cache
+	baseURL    string
+	batchURL   string
+	header     http.Header
+	retry      RetryPolicy
+	limiter    *rateLimiter
+	inFlight   flightGroup[*APIResponse]
+	infoFlight flightGroup[*VersionInfo]
+
+	requests     atomic.Int64
+	retries      atomic.Int64
+	retried      atomic.Int64
+	deduplicated atomic.Int64
+	// infoDeduplicated counts version details answered by another lookup or a batch
+	infoDeduplicated atomic.Int64
+	batched          atomic.Int64
+	noBatch          atomic.Bool
+}
+
+// ClientStats holds request counters of a Client
+type ClientStats struct {
+	Requests int64 `json:"requests"`
+	Retries  int64 `json:"retries"`
+	// RetriedPackages is the number of package lookups that needed at least one retry
+	RetriedPackages int64 `json:"retriedPackages"`
+	// Deduplicated is the number of lookups answered by another lookup of the same package
+	Deduplicated int64 `json:"deduplicated"`
+	// DeduplicatedVersionInfo is the number of version details answered by another lookup of
+	// the same version or by a batch request
+	DeduplicatedVersionInfo int64 `json:"deduplicatedVersionInfo"`
+	// BatchedVersions is the number of version details fetched with batch requests
+	BatchedVersions int64 `json:"batchedVersions"`
+}
+
+// ClientOption configures optional behaviour of a Client
+type ClientOption func(*Client)
+
+// WithCache makes the client consult and populate the given persistent cache
+func WithCache(cache *Cache) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// WithBaseURL points the client at a deps.dev-compatible API, e.g. an internal mirror. Batch
+// requests go to the matching v3alpha API if the URL ends in /v3 and are disabled otherwise.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		if baseURL == "" {
+			return
+		}
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+		c.batchURL = ""
+		if prefix, ok := strings.CutSuffix(c.baseURL, "/v3"); ok {
+			c.batchURL = prefix + "/v3alpha/versionbatch"
+		}
+	}
+}
+
+// WithHTTPClient replaces the HTTP client, e.g. to inject a transport created by NewTransport
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithHeaders adds headers such as authentication tokens to every API request
+func WithHeaders(header http.Header) ClientOption {
+	return func(c *Client) {
+		for key, values := range header {
+			for _, value := range values {
+				c.header.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithRetryPolicy overrides DefaultRetryPolicy for rate-limited and transient failures
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithRateLimit limits the client to the given number of HTTP requests per second,
+// including retries. A rate <= 0 disables the limit.
+func WithRateLimit(requestsPerSecond float64) ClientOption {
+	return func(c *Client) {
+		c.limiter = newRateLimiter(requestsPerSecond)
+	}
 }
 
 // NewClient creates a new deps.dev API client
-func NewClient(logger *slog.Logger) *Client {
+func NewClient(logger *slog.Logger, opts ...ClientOption) *Client {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	return &Client{
+	client := &Client{
 		httpClient: &http.Client{
 			Timeout: requestTimeout,
 		},
-		logger: logger,
+		logger:   logger,
+		baseURL:  depsDevAPIBase,
+		batchURL: depsDevBatchURL,
+		header:   http.Header{},
+		retry:    DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(client)
 	}
+
+	return client
 }
 
-// GetVersions retrieves all versions for a package identified by its PURL
+// Name identifies deps.dev as a version source
+func (c *Client) Name() string {
+	return "deps.dev"
+}
+
+// GetVersions retrieves all versions for a package identified by its PURL. Lookups of the
+// same package, e.g. for several versions in one SBOM, share a single request and the
+// returned response, which callers must not modify.
 func (c *Client) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
 	purl, err := packageurl.FromString(rawPURL)
 	if err != nil {
 		c.logger.Warn("Failed to parse PURL", "purl", rawPURL, "error", err)
-		return nil, fmt.Errorf("invalid PURL %q: %w", rawPURL, err)
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
 	}
 
 	c.logger.Debug("Starting deps.dev API query", "purl", purl.String())
 
-	name, system, err := c.getNameAndSystem(purl)
+	name, system, err := getNameAndSystem(purl)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract name and system from PURL: %w", err)
 	}
 
-	apiURL := fmt.Sprintf("%s/systems/%s/packages/%s", depsDevAPIBase, system, name)
+	resp, shared, err := c.inFlight.do(ctx, cacheKey(system, name), func() (*APIResponse, error) {
+		return c.fetchVersions(ctx, purl.String(), system, name)
+	})
+	if shared {
+		c.deduplicated.Add(1)
+		c.logger.Debug("Reused versions of concurrent or earlier lookup", "purl", purl.String())
+	}
+
+	return resp, err
+}
+
+// fetchVersions serves a package from the cache or requests it from deps.dev. Packages that
+// deps.dev does not know are cached as well.
+func (c *Client) fetchVersions(ctx context.Context, purl, system, name string) (*APIResponse, error) {
+	if c.cache != nil {
+		if cached, ok := c.cache.Get(c.baseURL, system, name); ok {
+			if cached == nil {
+				return nil, ErrPackageNotFound
+			}
+			return c.markSource(cached), nil
+		}
+	}
+
+	apiURL := fmt.Sprintf("%s/systems/%s/packages/%s", c.baseURL, system, name)
 	c.logger.Debug("Constructed API URL", "url", apiURL)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	var depsResp APIResponse
+	if err := c.requestWithRetry(ctx, purl, apiURL, nil, &depsResp); err != nil {
+		if c.cache != nil && errors.Is(err, ErrPackageNotFound) {
+			if err := c.cache.PutNotFound(c.baseURL, system, name); err != nil {
+				c.logger.Warn("Failed to cache unknown package", "purl", purl, "error", err)
+			}
+		}
+		return nil, err
+	}
+
+	c.logger.Debug("Successfully retrieved versions", "url", apiURL, "count", len(depsResp.Versions))
+
+	if c.cache != nil {
+		if err := c.cache.Put(c.baseURL, system, name, &depsResp); err != nil {
+			c.logger.Warn("Failed to cache versions", "purl", purl, "error", err)
+		}
+	}
+
+	return c.markSource(&depsResp), nil
+}
+
+// markSource records deps.dev as the source of a response
+func (c *Client) markSource(resp *APIResponse) *APIResponse {
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]string, 1)
+	}
+	resp.Metadata[MetadataSource] = c.Name()
+	return resp
+}
+
+// Stats returns the request and retry counters accumulated since the client was created
+func (c *Client) Stats() ClientStats {
+	return ClientStats{
+		Requests:                c.requests.Load(),
+		Retries:                 c.retries.Load(),
+		RetriedPackages:         c.retried.Load(),
+		Deduplicated:            c.deduplicated.Load(),
+		DeduplicatedVersionInfo: c.infoDeduplicated.Load(),
+		BatchedVersions:         c.batched.Load(),
+	}
+}
+
+// requestWithRetry requests apiURL, decodes the JSON response into out and retries rate-limited
+// and transient failures with exponential backoff until the retry policy's attempts or time
+// budget are exhausted. A non-nil body is sent as JSON POST request.
+func (c *Client) requestWithRetry(ctx context.Context, purl, apiURL string, body []byte, out any) error {
+	start := time.Now()
+	retries := 0
+
+	for attempt := 1; ; attempt++ {
+		retryAfter, retryable, err := c.request(ctx, apiURL, body, out)
+		if err == nil || !retryable || attempt >= c.retry.MaxAttempts {
+			if retries > 0 {
+				c.logger.Debug("Finished retried deps.dev request", "purl", purl, "retries", retries, "error", err)
+			}
+			return err
+		}
+
+		wait := c.retry.backoff(retries+1, retryAfter)
+		if c.retry.MaxElapsed > 0 && time.Since(start)+wait > c.retry.MaxElapsed {
+			c.logger.Debug("Retry budget exhausted", "purl", purl, "retries", retries, "wait", wait, "error", err)
+			return fmt.Errorf("%w (gave up after %d attempts)", err, attempt)
+		}
+
+		retries++
+		c.retries.Add(1)
+		if retries == 1 {
+			c.retried.Add(1)
+		}
+		c.logger.Debug("Retrying deps.dev request", "purl", purl, "attempt", attempt+1, "wait", wait, "error", err)
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return fmt.Errorf("retry cancelled: %w", err)
+		}
+	}
+}
+
+// request performs a single request decoding into out and reports whether a failure may be
+// retried, and after which delay the server asked to retry
+func (c *Client) request(ctx context.Context, apiURL string, body []byte, out any) (time.Duration, bool, error) {
+	method, reqBody := http.MethodGet, io.Reader(nil)
+	if body != nil {
+		method, reqBody = http.MethodPost, bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		return 0, false, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range c.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
 	}
 
+	if err := c.limiter.wait(ctx); err != nil {
+		return 0, false, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	c.requests.Add(1)
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		c.logger.Debug("HTTP request failed", "url", apiURL, "error", err)
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		return 0, isRetryableError(ctx, err), fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
@@ -97,40 +357,49 @@ func (c *Client) GetVersions(ctx context.Context, rawPURL string) (*APIResponse,
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, c.handleHTTPError(resp, apiURL)
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		return retryAfter, isRetryableStatus(resp.StatusCode), handleHTTPError(c.logger, resp, apiURL)
 	}
 
-	var depsResp APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&depsResp); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
 		c.logger.Debug("Failed to decode response", "url", apiURL, "error", err)
-		return nil, fmt.Errorf("failed to decode API response: %w", err)
+		return 0, false, fmt.Errorf("failed to decode API response: %w", err)
 	}
 
-	c.logger.Debug("Successfully retrieved versions", "url", apiURL, "count", len(depsResp.Versions))
-	return &depsResp, nil
+	return 0, false, nil
 }
 
 // handleHTTPError processes non-200 HTTP responses and returns appropriate errors
-func (c *Client) handleHTTPError(resp *http.Response, url string) error {
+func handleHTTPError(logger *slog.Logger, resp *http.Response, url string) error {
 	switch resp.StatusCode {
 	case http.StatusTooManyRequests:
 		retryAfter := resp.Header.Get("Retry-After")
-		c.logger.Debug("Rate limited by API", "retry-after", retryAfter, "url", url)
-		return fmt.Errorf("rate limited (retry after: %s)", retryAfter)
-	case http.StatusNotFound:
-		c.logger.Debug("Package not found", "url", url)
-		return fmt.Errorf("package not found")
+		logger.Debug("Rate limited by API", "retry-after", retryAfter, "url", url)
+		return fmt.Errorf("%w (retry after: %s)", ErrRateLimited, retryAfter)
+	case http.StatusNotFound, http.StatusGone:
+		logger.Debug("Package not found", "url", url)
+		return ErrPackageNotFound
 	case http.StatusBadRequest:
-		c.logger.Debug("Bad request", "url", url)
+		logger.Debug("Bad request", "url", url)
 		return fmt.Errorf("invalid request")
 	default:
-		c.logger.Debug("HTTP request failed", "url", url, "status", resp.StatusCode)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		logger.Debug("HTTP request failed", "url", url, "status", resp.StatusCode)
+		return &httpStatusError{statusCode: resp.StatusCode, status: resp.Status}
 	}
 }
 
+// httpStatusError is returned for HTTP status codes without a more specific error
+type httpStatusError struct {
+	statusCode int
+	status     string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.statusCode, e.status)
+}
+
 // getNameAndSystem extracts the package name and system from a PURL
-func (c *Client) getNameAndSystem(purl packageurl.PackageURL) (name, system string, err error) {
+func getNameAndSystem(purl packageurl.PackageURL) (name, system string, err error) {
 	name = purl.Name
 	if purl.Namespace != "" {
 		name = purl.Namespace + "/" + name
@@ -157,7 +426,7 @@ func (c *Client) getNameAndSystem(purl packageurl.PackageURL) (name, system stri
 	case packageurl.TypeGem:
 		system = "rubygems"
 	default:
-		return "", "", fmt.Errorf("unsupported package type: %s", purl.Type)
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
 	}
 
 	// URL encode the name and system to handle special characters
@@ -165,7 +434,7 @@ func (c *Client) getNameAndSystem(purl packageurl.PackageURL) (name, system stri
 	system = url.PathEscape(system)
 
 	if name == "" || system == "" {
-		return "", "", fmt.Errorf("failed to extract name or system from PURL: name=%q, system=%q", name, system)
+		return "", "", fmt.Errorf("%w: failed to extract name or system: name=%q, system=%q", ErrInvalidPURL, name, system)
 	}
 
 	return name, system, nil
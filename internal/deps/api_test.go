@@ -0,0 +1,206 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testDepsDevResponse = `{"versions":[{"versionKey":{"system":"NPM","name":"vue","version":"3.5.17"},"publishedAt":"2025-06-18T10:00:00Z"}]}`
+
+// newTestClient returns a client talking to server with fast retries
+func newTestClient(server *httptest.Server, opts ...ClientOption) *Client {
+	opts = append([]ClientOption{WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		MaxElapsed:  time.Second,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	})}, opts...)
+	client := NewClient(nil, opts...)
+	client.baseURL = server.URL
+	return client
+}
+
+func TestClientRetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(testDepsDevResponse))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	resp, err := client.GetVersions(context.Background(), "pkg:npm/vue@3.5.17")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 1 {
+		t.Fatalf("expected 1 version, got %d", len(resp.Versions))
+	}
+
+	stats := client.Stats()
+	if stats.Requests != 3 || stats.Retries != 2 || stats.RetriedPackages != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestClientGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	if _, err := newTestClient(server).GetVersions(context.Background(), "pkg:npm/vue@3.5.17"); err == nil {
+		t.Fatalf("expected error after exhausting retries")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestClientDoesNotRetryNotFound(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server).GetVersions(context.Background(), "pkg:npm/vue@3.5.17")
+	if !errors.Is(err, ErrPackageNotFound) {
+		t.Fatalf("expected ErrPackageNotFound, got: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestClientCachesUnknownPackages(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cache, err := NewCache(t.TempDir(), time.Hour, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	// Each client stands for a run; the second one is served from disk
+	for range 2 {
+		_, err := newTestClient(server, WithCache(cache)).GetVersions(context.Background(), "pkg:golang/github.com/acme/lib/v2")
+		if !errors.Is(err, ErrPackageNotFound) {
+			t.Fatalf("expected ErrPackageNotFound, got: %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", calls.Load())
+	}
+}
+
+func TestClientRespectsRetryBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	if _, err := newTestClient(server).GetVersions(context.Background(), "pkg:npm/vue@3.5.17"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited when Retry-After exceeds the budget, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected client to give up immediately, took %v", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if d := parseRetryAfter("7"); d != 7*time.Second {
+		t.Errorf("expected 7s, got %v", d)
+	}
+	if d := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); d < 59*time.Minute {
+		t.Errorf("expected about an hour, got %v", d)
+	}
+	if d := parseRetryAfter("soon"); d != 0 {
+		t.Errorf("expected 0 for invalid value, got %v", d)
+	}
+}
+
+func TestClientDeduplicatesPackageLookups(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(testDepsDevResponse))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	purls := []string{"pkg:npm/vue@3.5.17", "pkg:npm/vue@3.4.0", "pkg:npm/vue@2.7.16", "pkg:npm/vue@3.5.17"}
+
+	errs := make(chan error, len(purls))
+	for _, purl := range purls {
+		go func() {
+			_, err := client.GetVersions(context.Background(), purl)
+			errs <- err
+		}()
+	}
+
+	// Give all lookups time to join the in-flight request before answering it
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for range purls {
+		if err := <-errs; err != nil {
+			t.Fatalf("no error expected, got: %v", err)
+		}
+	}
+
+	// A later lookup reuses the completed result
+	if _, err := client.GetVersions(context.Background(), "pkg:npm/vue@3.0.0"); err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("expected a single HTTP request, got %d", calls.Load())
+	}
+	if stats := client.Stats(); stats.Deduplicated != 4 {
+		t.Errorf("expected 4 deduplicated lookups, got %d", stats.Deduplicated)
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testDepsDevResponse))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server, WithRateLimit(50))
+
+	start := time.Now()
+	for _, purl := range []string{"pkg:npm/a@1.0.0", "pkg:npm/b@1.0.0", "pkg:npm/c@1.0.0", "pkg:npm/d@1.0.0", "pkg:npm/e@1.0.0"} {
+		if _, err := client.GetVersions(context.Background(), purl); err != nil {
+			t.Fatalf("no error expected, got: %v", err)
+		}
+	}
+
+	// Five requests at 50 per second need at least four 20ms intervals
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected requests to be spaced by the rate limit, took only %v", elapsed)
+	}
+}
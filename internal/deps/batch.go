@@ -0,0 +1,174 @@
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// versionBatchSize is the maximum number of versions deps.dev accepts in one batch request
+const versionBatchSize = 5000
+
+// VersionInfoRequest identifies a package version whose details are requested
+type VersionInfoRequest struct {
+	PURL    string
+	Version string
+}
+
+// VersionInfoPrefetcher loads the details of many versions at once, so that subsequent
+// GetVersionInfo calls for them are answered without further requests
+type VersionInfoPrefetcher interface {
+	PrefetchVersionInfo(ctx context.Context, requests []VersionInfoRequest) error
+}
+
+// batchVersionKey is a version key as used in deps.dev batch requests
+type batchVersionKey struct {
+	System  string `json:"system"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// batchTarget is the lookup a version of a batch request answers
+type batchTarget struct {
+	key, system, name string
+}
+
+// batchRequestItem is one version of a deps.dev GetVersionBatch request
+type batchRequestItem struct {
+	VersionKey batchVersionKey `json:"versionKey"`
+}
+
+// versionBatchRequest is the body of a deps.dev GetVersionBatch request
+type versionBatchRequest struct {
+	Requests  []batchRequestItem `json:"requests"`
+	PageToken string             `json:"pageToken,omitempty"`
+}
+
+// versionBatchResponse is one page of a deps.dev GetVersionBatch response
+type versionBatchResponse struct {
+	Responses []struct {
+		Request batchRequestItem    `json:"request"`
+		Version *versionAPIResponse `json:"version"`
+	} `json:"responses"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// PrefetchVersionInfo fetches the details of all requested versions with deps.dev batch
+// requests. Versions in the persistent cache are taken from there. Versions that are already
+// known, unsupported or missing from the batch results are left to GetVersionInfo, which also
+// serves as fallback if batching is unavailable.
+func (c *Client) PrefetchVersionInfo(ctx context.Context, requests []VersionInfoRequest) error {
+	if c.batchURL == "" || c.noBatch.Load() {
+		return nil
+	}
+
+	keys := make(map[batchVersionKey]batchTarget)
+	for _, r := range requests {
+		purl, err := packageurl.FromString(r.PURL)
+		if err != nil || r.Version == "" {
+			continue
+		}
+		name, system, err := getNameAndSystem(purl)
+		if err != nil {
+			continue
+		}
+		key := versionInfoKey(system, name, r.Version)
+		if c.infoFlight.has(key) {
+			continue
+		}
+		if c.cache != nil {
+			if cached, ok := c.cache.GetVersionInfo(c.baseURL, system, name, r.Version); ok {
+				c.infoFlight.store(key, cached)
+				continue
+			}
+		}
+		rawName, err := url.PathUnescape(name)
+		if err != nil {
+			continue
+		}
+		keys[batchVersionKey{System: strings.ToUpper(system), Name: rawName, Version: r.Version}] = batchTarget{key: key, system: system, name: name}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	batch := make([]batchVersionKey, 0, min(len(keys), versionBatchSize))
+	for versionKey := range keys {
+		batch = append(batch, versionKey)
+		if len(batch) == versionBatchSize {
+			if err := c.fetchVersionBatch(ctx, batch, keys); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		return c.fetchVersionBatch(ctx, batch, keys)
+	}
+
+	return nil
+}
+
+// fetchVersionBatch requests all pages of one batch and stores the returned details
+func (c *Client) fetchVersionBatch(ctx context.Context, batch []batchVersionKey, keys map[batchVersionKey]batchTarget) error {
+	body := versionBatchRequest{Requests: make([]batchRequestItem, 0, len(batch))}
+	for _, versionKey := range batch {
+		body.Requests = append(body.Requests, batchRequestItem{VersionKey: versionKey})
+	}
+
+	c.logger.Debug("Fetching version details in batch", "url", c.batchURL, "versions", len(batch))
+
+	for page := 1; ; page++ {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode batch request: %w", err)
+		}
+
+		var resp versionBatchResponse
+		if err := c.requestWithRetry(ctx, "batch", c.batchURL, data, &resp); err != nil {
+			// Mirrors may not offer batches; do not try again once the endpoint is known to be
+			// missing. Other failures, e.g. rate limits, only fall back for this batch.
+			if batchUnavailable(err) {
+				c.logger.Debug("Batch endpoint unavailable, falling back to single requests", "url", c.batchURL, "error", err)
+				c.noBatch.Store(true)
+			} else {
+				c.logger.Debug("Batch request failed, falling back to single requests for this batch", "url", c.batchURL, "error", err)
+			}
+			return fmt.Errorf("batch request failed: %w", err)
+		}
+
+		for _, r := range resp.Responses {
+			target, ok := keys[r.Request.VersionKey]
+			if !ok || r.Version == nil {
+				continue
+			}
+			info := r.Version.versionInfo(r.Request.VersionKey.Version)
+			c.infoFlight.store(target.key, info)
+			c.cacheVersionInfo(target.system, target.name, r.Request.VersionKey.Version, info)
+			c.batched.Add(1)
+		}
+
+		if resp.NextPageToken == "" {
+			c.logger.Debug("Fetched version details in batch", "versions", len(batch), "pages", page)
+			return nil
+		}
+		body.PageToken = resp.NextPageToken
+	}
+}
+
+// batchUnavailable reports whether a batch request failed because the API does not offer
+// batches, i.e. with 404 Not Found, 405 Method Not Allowed or 501 Not Implemented
+func batchUnavailable(err error) bool {
+	if errors.Is(err, ErrPackageNotFound) {
+		return true
+	}
+	var statusErr *httpStatusError
+	return errors.As(err, &statusErr) &&
+		(statusErr.statusCode == http.StatusMethodNotAllowed || statusErr.statusCode == http.StatusNotImplemented)
+}
@@ -0,0 +1,144 @@
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientPrefetchVersionInfo(t *testing.T) {
+	var batchCalls, singleCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3alpha/versionbatch" {
+			singleCalls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		batchCalls.Add(1)
+
+		var req versionBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if len(req.Requests) != 2 {
+			t.Errorf("expected 2 versions in batch, got %d", len(req.Requests))
+		}
+
+		// Answer one version per page
+		item := req.Requests[0]
+		next := "page2"
+		if req.PageToken == "page2" {
+			item, next = req.Requests[1], ""
+		}
+		resp := map[string]any{
+			"responses": []map[string]any{{
+				"request": item,
+				"version": map[string]any{"versionKey": item.VersionKey, "isDeprecated": item.VersionKey.Name == "request"},
+			}},
+			"nextPageToken": next,
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server, WithBaseURL(server.URL+"/v3"))
+	err := client.PrefetchVersionInfo(context.Background(), []VersionInfoRequest{
+		{PURL: "pkg:npm/request@2.88.2", Version: "2.88.2"},
+		{PURL: "pkg:npm/%40vue/core@3.5.17", Version: "3.5.17"},
+		{PURL: "pkg:generic/unsupported@1.0.0", Version: "1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	info, err := client.GetVersionInfo(context.Background(), "pkg:npm/request@2.88.2", "2.88.2")
+	if err != nil || !info.IsDeprecated {
+		t.Errorf("expected prefetched deprecated version, got %+v, %v", info, err)
+	}
+	if _, err := client.GetVersionInfo(context.Background(), "pkg:npm/%40vue/core@3.5.17", "3.5.17"); err != nil {
+		t.Errorf("no error expected, got: %v", err)
+	}
+
+	if batchCalls.Load() != 2 || singleCalls.Load() != 0 {
+		t.Errorf("expected 2 batch pages and no single requests, got %d and %d", batchCalls.Load(), singleCalls.Load())
+	}
+	if stats := client.Stats(); stats.BatchedVersions != 2 || stats.DeduplicatedVersionInfo != 2 || stats.Deduplicated != 0 {
+		t.Errorf("expected 2 batched versions counted apart from package lookups, got %+v", stats)
+	}
+}
+
+func TestClientPrefetchFallsBackToSingleRequests(t *testing.T) {
+	var batchCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v3alpha/versionbatch" {
+			batchCalls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"versionKey":{"version":"2.88.2"},"isDeprecated":true}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server, WithBaseURL(server.URL+"/v3"))
+	requests := []VersionInfoRequest{{PURL: "pkg:npm/request@2.88.2", Version: "2.88.2"}}
+	if err := client.PrefetchVersionInfo(context.Background(), requests); err == nil {
+		t.Fatal("expected batch error")
+	}
+	// Batching is not tried again after it failed
+	if err := client.PrefetchVersionInfo(context.Background(), requests); err != nil || batchCalls.Load() != 1 {
+		t.Errorf("expected no second batch request, got %d calls, error %v", batchCalls.Load(), err)
+	}
+
+	info, err := client.GetVersionInfo(context.Background(), "pkg:npm/request@2.88.2", "2.88.2")
+	if err != nil || !info.IsDeprecated {
+		t.Errorf("expected version details from single request, got %+v, %v", info, err)
+	}
+}
+
+func TestClientPrefetchRetriesBatchAfterTransientFailure(t *testing.T) {
+	var batchCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batchCalls.Add(1)
+		if batchCalls.Load() <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"responses": []}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server, WithBaseURL(server.URL+"/v3"))
+	requests := []VersionInfoRequest{{PURL: "pkg:npm/request@2.88.2", Version: "2.88.2"}}
+	if err := client.PrefetchVersionInfo(context.Background(), requests); err == nil {
+		t.Fatal("expected batch error once retries are exhausted")
+	}
+	// A transient failure does not turn batching off for the rest of the run
+	if err := client.PrefetchVersionInfo(context.Background(), requests); err != nil || batchCalls.Load() != 4 {
+		t.Errorf("expected the next batch to be requested, got %d calls, error %v", batchCalls.Load(), err)
+	}
+
+	for _, status := range []int{http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		if !batchUnavailable(handleHTTPError(client.logger, &http.Response{StatusCode: status}, "")) {
+			t.Errorf("expected HTTP %d to mark batches as unavailable", status)
+		}
+	}
+}
+
+func TestWithBaseURLDerivesBatchURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		batchURL string
+	}{
+		{"https://depsdev.acme.local/v3/", "https://depsdev.acme.local/v3alpha/versionbatch"},
+		{"https://depsdev.acme.local/api", ""},
+	}
+	for _, tt := range tests {
+		if got := NewClient(nil, WithBaseURL(tt.baseURL)).batchURL; got != tt.batchURL {
+			t.Errorf("WithBaseURL(%q): expected batch URL %q, got %q", tt.baseURL, tt.batchURL, got)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package deps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultCacheTTL is the time after which cached version lookups are considered stale
+	DefaultCacheTTL = 24 * time.Hour
+	cacheDirName    = "sbom-technical-lag"
+)
+
+// cacheEntry is the on-disk representation of a cached version lookup, holding either the
+// versions of a package, the details of one version or the marker of an unknown package
+type cacheEntry struct {
+	Key       string       `json:"key"`
+	FetchedAt time.Time    `json:"fetchedAt"`
+	Response  *APIResponse `json:"response,omitempty"`
+	Info      *VersionInfo `json:"info,omitempty"`
+	NotFound  bool         `json:"notFound,omitempty"`
+}
+
+// CacheStats holds the hit and miss counters of a cache
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// Cache persists version lookups on disk, keyed by API base URL, ecosystem and package name, so
+// that deps.dev and a mirror do not share entries
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	refresh bool
+	logger  *slog.Logger
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCache creates a cache rooted at dir. Entries older than ttl are ignored, a ttl <= 0
+// disables expiry. In refresh mode all lookups miss, so every entry is fetched and rewritten.
+func NewCache(dir string, ttl time.Duration, refresh bool, logger *slog.Logger) (*Cache, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if dir == "" {
+		return nil, errors.New("cache directory cannot be empty")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %q: %w", dir, err)
+	}
+
+	return &Cache{
+		dir:     dir,
+		ttl:     ttl,
+		refresh: refresh,
+		logger:  logger,
+	}, nil
+}
+
+// DefaultCacheDir returns the default cache location inside the user's cache directory
+func DefaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine user cache directory: %w", err)
+	}
+	return filepath.Join(base, cacheDirName), nil
+}
+
+// Get returns the cached response of the API at baseURL for a package if present and not
+// expired. A nil response reports a package that was stored with PutNotFound.
+func (c *Cache) Get(baseURL, system, name string) (*APIResponse, bool) {
+	entry, ok := c.read(c.key(baseURL, cacheKey(system, name)), func(entry cacheEntry) bool {
+		return entry.Response != nil || entry.NotFound
+	})
+	return entry.Response, ok
+}
+
+// Put stores the response of the API at baseURL for a package, replacing any previous entry
+func (c *Cache) Put(baseURL, system, name string, resp *APIResponse) error {
+	return c.write(cacheEntry{Key: c.key(baseURL, cacheKey(system, name)), Response: resp})
+}
+
+// PutNotFound records that the API at baseURL does not know a package, so that lookups of
+// packages that may never exist, such as the successor paths of Go modules, are not repeated
+// until the entry expires
+func (c *Cache) PutNotFound(baseURL, system, name string) error {
+	return c.write(cacheEntry{Key: c.key(baseURL, cacheKey(system, name)), NotFound: true})
+}
+
+// GetVersionInfo returns the cached details of the API at baseURL for a package version if
+// present and not expired
+func (c *Cache) GetVersionInfo(baseURL, system, name, version string) (*VersionInfo, bool) {
+	entry, ok := c.read(c.key(baseURL, versionInfoKey(system, name, version)), func(entry cacheEntry) bool {
+		return entry.Info != nil
+	})
+	return entry.Info, ok
+}
+
+// PutVersionInfo stores the details of the API at baseURL for a package version, replacing any
+// previous entry
+func (c *Cache) PutVersionInfo(baseURL, system, name, version string, info *VersionInfo) error {
+	return c.write(cacheEntry{Key: c.key(baseURL, versionInfoKey(system, name, version)), Info: info})
+}
+
+// read returns the entry for key if it is present, valid and not expired
+func (c *Cache) read(key string, valid func(cacheEntry) bool) (cacheEntry, bool) {
+	if c.refresh {
+		c.misses.Add(1)
+		return cacheEntry{}, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.logger.Debug("Failed to read cache entry", "key", key, "error", err)
+		}
+		c.misses.Add(1)
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || !valid(entry) || entry.Key != key {
+		c.logger.Debug("Ignoring corrupt cache entry", "key", key, "error", err)
+		c.misses.Add(1)
+		return cacheEntry{}, false
+	}
+
+	if c.ttl > 0 && time.Since(entry.FetchedAt) > c.ttl {
+		c.logger.Debug("Cache entry expired", "key", key, "fetched_at", entry.FetchedAt)
+		c.misses.Add(1)
+		return cacheEntry{}, false
+	}
+
+	c.logger.Debug("Cache hit", "key", key)
+	c.hits.Add(1)
+	return entry, true
+}
+
+// write stores an entry, replacing any previous entry with the same key
+func (c *Cache) write(entry cacheEntry) error {
+	entry.FetchedAt = time.Now().UTC()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see partial entries
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(entry.Key)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// Stats returns the hit and miss counters accumulated since the cache was created
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// key builds the entry key from the API base URL and the key of a package or version lookup
+func (c *Cache) key(baseURL, lookupKey string) string {
+	return strings.TrimSuffix(baseURL, "/") + " " + lookupKey
+}
+
+// path maps a cache key to its file; keys are hashed to stay filesystem-safe
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// cacheKey builds the lookup key from ecosystem and package name
+func cacheKey(system, name string) string {
+	return system + "/" + name
+}
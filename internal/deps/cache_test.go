@@ -0,0 +1,101 @@
+package deps
+
+import (
+	"testing"
+	"time"
+)
+
+func testResponse() *APIResponse {
+	return &APIResponse{
+		Versions: []VersionsAPIResponse{
+			{Version: Version{Version: "1.0.0"}, PublishedAt: "2021-01-20T14:45:30Z"},
+			{Version: Version{Version: "1.1.0"}, PublishedAt: "2021-08-05T09:15:45Z"},
+		},
+	}
+}
+
+const testBaseURL = "https://api.deps.dev/v3"
+
+func TestCachePutGet(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), time.Hour, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	if _, ok := cache.Get(testBaseURL, "npm", "vue"); ok {
+		t.Fatalf("expected miss on empty cache")
+	}
+
+	if err := cache.Put(testBaseURL, "npm", "vue", testResponse()); err != nil {
+		t.Fatalf("failed to put entry: %v", err)
+	}
+
+	resp, ok := cache.Get(testBaseURL, "npm", "vue")
+	if !ok {
+		t.Fatalf("expected hit after put")
+	}
+	if len(resp.Versions) != 2 || resp.Versions[1].Version.Version != "1.1.0" {
+		t.Fatalf("unexpected cached response: %+v", resp)
+	}
+
+	if _, ok := cache.Get(testBaseURL, "cargo", "vue"); ok {
+		t.Fatalf("expected entries to be keyed by ecosystem")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), time.Nanosecond, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	if err := cache.Put(testBaseURL, "npm", "vue", testResponse()); err != nil {
+		t.Fatalf("failed to put entry: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, ok := cache.Get(testBaseURL, "npm", "vue"); ok {
+		t.Fatalf("expected expired entry to miss")
+	}
+}
+
+func TestCacheRefresh(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir, 0, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	if err := cache.Put(testBaseURL, "npm", "vue", testResponse()); err != nil {
+		t.Fatalf("failed to put entry: %v", err)
+	}
+
+	refreshing, err := NewCache(dir, 0, true, nil)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	if _, ok := refreshing.Get(testBaseURL, "npm", "vue"); ok {
+		t.Fatalf("expected refresh mode to ignore existing entries")
+	}
+}
+
+func TestCacheKeyedByBaseURL(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), time.Hour, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	if err := cache.Put(testBaseURL, "npm", "vue", testResponse()); err != nil {
+		t.Fatalf("failed to put entry: %v", err)
+	}
+
+	if _, ok := cache.Get("https://mirror.example.com/v3", "npm", "vue"); ok {
+		t.Fatalf("expected entries of deps.dev to miss for a mirror")
+	}
+	if _, ok := cache.Get(testBaseURL+"/", "npm", "vue"); !ok {
+		t.Fatalf("expected a trailing slash not to change the key")
+	}
+}
@@ -0,0 +1,219 @@
+package deps
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// DefaultCargoIndex is the sparse index of crates.io
+const DefaultCargoIndex = "https://index.crates.io"
+
+// cargoUserAgent identifies this tool, crates.io rejects API requests without a user agent
+const cargoUserAgent = "sbom-technical-lag (https://github.com/fraunhofer-iem/Technical-Lag-Analyzer)"
+
+// cargoIndexConfig is the config.json at the root of a registry index
+type cargoIndexConfig struct {
+	API string `json:"api"`
+}
+
+// cargoIndexEntry is one line of a sparse index file
+type cargoIndexEntry struct {
+	Version string `json:"vers"`
+	Yanked  bool   `json:"yanked"`
+}
+
+// cargoVersionsPage is a page of the registry web API versions endpoint
+type cargoVersionsPage struct {
+	Versions []struct {
+		Num       string `json:"num"`
+		CreatedAt string `json:"created_at"`
+		Yanked    bool   `json:"yanked"`
+	} `json:"versions"`
+	Meta struct {
+		NextPage string `json:"next_page"`
+	} `json:"meta"`
+}
+
+// CargoSource reads version histories from crates.io or an alternate Cargo registry. The
+// sparse index provides the versions and their yanked status, the registry web API adds
+// publication dates where available.
+type CargoSource struct {
+	indexURL   string
+	token      string
+	httpClient *http.Client
+	logger     *slog.Logger
+
+	// apiFlight memoises the web API URL once config.json was read; failed reads are retried
+	apiFlight flightGroup[string]
+}
+
+// NewCargoSource creates a source for the sparse index at indexURL, defaulting to crates.io.
+// The optional token is sent as Authorization header like cargo does. A nil httpClient uses default settings.
+func NewCargoSource(indexURL, token string, httpClient *http.Client, logger *slog.Logger) *CargoSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if indexURL == "" {
+		indexURL = DefaultCargoIndex
+	}
+
+	return &CargoSource{
+		indexURL:   strings.TrimSuffix(strings.TrimPrefix(indexURL, "sparse+"), "/"),
+		token:      token,
+		httpClient: defaultHTTPClient(httpClient),
+		logger:     logger,
+	}
+}
+
+// Name identifies the Cargo registry as a version source
+func (c *CargoSource) Name() string {
+	return "cargo"
+}
+
+// ServesRepository reports whether repositoryURL is the configured index
+func (c *CargoSource) ServesRepository(purlType, repositoryURL string) bool {
+	return purlType == packageurl.TypeCargo && sameRepository(c.indexURL, repositoryURL)
+}
+
+// GetVersions returns all versions of a crate with yanked status and, if the registry has
+// a web API, their publication dates
+func (c *CargoSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeCargo {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	name := strings.ToLower(purl.Name)
+	indexFileURL := c.indexURL + "/" + cargoIndexPath(name)
+	c.logger.Debug("Fetching cargo index file", "purl", rawPURL, "url", indexFileURL)
+
+	var entries []cargoIndexEntry
+	err = fetch(ctx, c.httpClient, c.logger, indexFileURL, c.header(), func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var entry cargoIndexEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := c.getPublicationDates(ctx, name)
+
+	versions := make([]VersionsAPIResponse, 0, len(entries))
+	for _, entry := range entries {
+		versions = append(versions, VersionsAPIResponse{Version: Version{
+			Version:     entry.Version,
+			PublishedAt: createdAt[entry.Version],
+			Yanked:      entry.Yanked,
+		}})
+	}
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	c.logger.Debug("Successfully retrieved cargo versions", "url", indexFileURL, "count", len(versions), "dated", len(createdAt))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"index": c.indexURL},
+	}, nil
+}
+
+// getPublicationDates reads created_at for each version from the registry web API. Failures
+// are logged and yield no dates, since the index alone is still enough for version distance.
+func (c *CargoSource) getPublicationDates(ctx context.Context, name string) map[string]string {
+	dates := make(map[string]string)
+
+	apiURL := c.api(ctx)
+	if apiURL == "" {
+		return dates
+	}
+
+	pageURL := apiURL + "/api/v1/crates/" + url.PathEscape(name) + "/versions"
+	for pageURL != "" {
+		var page cargoVersionsPage
+		if err := fetchJSON(ctx, c.httpClient, c.logger, pageURL, c.header(), &page); err != nil {
+			c.logger.Debug("Failed to fetch crate publication dates", "url", pageURL, "error", err)
+			return dates
+		}
+		for _, v := range page.Versions {
+			dates[v.Num] = v.CreatedAt
+		}
+
+		// next_page is a query string relative to the endpoint
+		next := page.Meta.NextPage
+		if next == "" {
+			break
+		}
+		base, _, _ := strings.Cut(pageURL, "?")
+		pageURL = base + "?" + strings.TrimPrefix(next, "?")
+	}
+
+	return dates
+}
+
+// api returns the web API URL announced in the index config.json. It is fetched once per
+// source; after a failure, e.g. a timeout, the next crate tries again.
+func (c *CargoSource) api(ctx context.Context) string {
+	configURL := c.indexURL + "/config.json"
+	apiURL, _, err := c.apiFlight.do(ctx, configURL, func() (string, error) {
+		var config cargoIndexConfig
+		if err := fetchJSON(ctx, c.httpClient, c.logger, configURL, c.header(), &config); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(config.API, "/"), nil
+	})
+	if err != nil {
+		c.logger.Warn("Failed to read cargo index config, publication dates unavailable", "url", configURL, "error", err)
+		return ""
+	}
+	return apiURL
+}
+
+// header returns the headers sent with every registry request
+func (c *CargoSource) header() http.Header {
+	header := http.Header{}
+	header.Set("User-Agent", cargoUserAgent)
+	if c.token != "" {
+		header.Set("Authorization", c.token)
+	}
+	return header
+}
+
+// cargoIndexPath returns the location of a crate's file in the index directory layout
+func cargoIndexPath(name string) string {
+	switch len(name) {
+	case 1:
+		return "1/" + name
+	case 2:
+		return "2/" + name
+	case 3:
+		return "3/" + name[:1] + "/" + name
+	default:
+		return name[:2] + "/" + name[2:4] + "/" + name
+	}
+}
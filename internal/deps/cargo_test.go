@@ -0,0 +1,101 @@
+package deps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCargoIndexPath(t *testing.T) {
+	testCases := map[string]string{
+		"a":     "1/a",
+		"ab":    "2/ab",
+		"abc":   "3/a/abc",
+		"serde": "se/rd/serde",
+	}
+	for name, expected := range testCases {
+		if got := cargoIndexPath(name); got != expected {
+			t.Errorf("cargoIndexPath(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestCargoSource(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" || r.Header.Get("Authorization") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.URL.Path == "/index/config.json":
+			_, _ = w.Write([]byte(`{"dl": "` + server.URL + `/dl", "api": "` + server.URL + `/"}`))
+		case r.URL.Path == "/index/ac/me/acme-core":
+			_, _ = w.Write([]byte(
+				`{"name":"acme-core","vers":"0.1.0","deps":[],"cksum":"","features":{},"yanked":false}` + "\n" +
+					`{"name":"acme-core","vers":"0.2.0","deps":[],"cksum":"","features":{},"yanked":true}` + "\n"))
+		case r.URL.Path == "/api/v1/crates/acme-core/versions" && r.URL.RawQuery == "":
+			_, _ = w.Write([]byte(`{"versions":[{"num":"0.2.0","created_at":"2024-02-01T00:00:00Z","yanked":true}],"meta":{"next_page":"?page=2"}}`))
+		case r.URL.Path == "/api/v1/crates/acme-core/versions" && r.URL.RawQuery == "page=2":
+			_, _ = w.Write([]byte(`{"versions":[{"num":"0.1.0","created_at":"2024-01-01T00:00:00Z","yanked":false}],"meta":{"next_page":null}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := NewCargoSource("sparse+"+server.URL+"/index/", "token", nil, nil)
+	resp, err := source.GetVersions(context.Background(), "pkg:cargo/acme-core@0.1.0")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(resp.Versions))
+	}
+
+	first, second := resp.Versions[0].Version, resp.Versions[1].Version
+	if first.Version != "0.1.0" || first.PublishedAt != "2024-01-01T00:00:00Z" || first.Yanked {
+		t.Errorf("unexpected first version: %+v", first)
+	}
+	if second.Version != "0.2.0" || second.PublishedAt != "2024-02-01T00:00:00Z" || !second.Yanked {
+		t.Errorf("unexpected second version: %+v", second)
+	}
+}
+
+func TestCargoSourceRetriesIndexConfig(t *testing.T) {
+	var server *httptest.Server
+	configRequests := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.json":
+			configRequests++
+			if configRequests == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"api": "` + server.URL + `"}`))
+		case "/se/rd/serde":
+			_, _ = w.Write([]byte(`{"name":"serde","vers":"1.0.0","yanked":false}` + "\n"))
+		case "/api/v1/crates/serde/versions":
+			_, _ = w.Write([]byte(`{"versions":[{"num":"1.0.0","created_at":"2024-01-01T00:00:00Z"}],"meta":{}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := NewCargoSource(server.URL, "", nil, nil)
+	for i, expected := range []string{"", "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z"} {
+		resp, err := source.GetVersions(context.Background(), "pkg:cargo/serde@1.0.0")
+		if err != nil {
+			t.Fatalf("no error expected, got: %v", err)
+		}
+		if got := resp.Versions[0].Version.PublishedAt; got != expected {
+			t.Errorf("lookup %d: expected publication date %q, got %q", i, expected, got)
+		}
+	}
+	if configRequests != 2 {
+		t.Errorf("expected config.json to be read until it succeeds, got %d requests", configRequests)
+	}
+}
@@ -0,0 +1,103 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// DefaultCocoaPodsTrunk is the CocoaPods trunk API
+const DefaultCocoaPodsTrunk = "https://trunk.cocoapods.org"
+
+// cocoaPodsTimeLayout is the timestamp format of the trunk API
+const cocoaPodsTimeLayout = "2006-01-02 15:04:05 MST"
+
+// cocoaPodsPod is the subset of the trunk API pod response used for version histories
+type cocoaPodsPod struct {
+	Versions []struct {
+		Name      string `json:"name"`
+		CreatedAt string `json:"created_at"`
+	} `json:"versions"`
+}
+
+// CocoaPodsSource reads version histories of pods from the CocoaPods trunk API
+type CocoaPodsSource struct {
+	trunkURL   string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewCocoaPodsSource creates a source for the trunk API at trunkURL, defaulting to
+// trunk.cocoapods.org. A nil httpClient uses default settings.
+func NewCocoaPodsSource(trunkURL string, httpClient *http.Client, logger *slog.Logger) *CocoaPodsSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if trunkURL == "" {
+		trunkURL = DefaultCocoaPodsTrunk
+	}
+
+	return &CocoaPodsSource{
+		trunkURL:   strings.TrimSuffix(trunkURL, "/"),
+		httpClient: defaultHTTPClient(httpClient),
+		logger:     logger,
+	}
+}
+
+// Name identifies CocoaPods trunk as a version source
+func (c *CocoaPodsSource) Name() string {
+	return "cocoapods"
+}
+
+// ServesRepository reports whether repositoryURL is the configured trunk
+func (c *CocoaPodsSource) ServesRepository(purlType, repositoryURL string) bool {
+	return purlType == packageurl.TypeCocoapods && sameRepository(c.trunkURL, repositoryURL)
+}
+
+// GetVersions returns all pushed versions of a pod. Subspecs given as PURL subpath share
+// the versions of their pod.
+func (c *CocoaPodsSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeCocoapods {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	podURL := c.trunkURL + "/api/v1/pods/" + url.PathEscape(purl.Name)
+	c.logger.Debug("Fetching pod", "purl", rawPURL, "url", podURL)
+
+	var pod cocoaPodsPod
+	if err := fetchJSON(ctx, c.httpClient, c.logger, podURL, nil, &pod); err != nil {
+		return nil, err
+	}
+
+	versions := make([]VersionsAPIResponse, 0, len(pod.Versions))
+	for _, v := range pod.Versions {
+		version := Version{Version: v.Name}
+		if t, err := time.Parse(cocoaPodsTimeLayout, v.CreatedAt); err == nil {
+			version.PublishedAt = t.UTC().Format(time.RFC3339)
+		} else {
+			c.logger.Debug("Failed to parse pod version time", "version", v.Name, "created_at", v.CreatedAt, "error", err)
+		}
+		versions = append(versions, VersionsAPIResponse{Version: version})
+	}
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	c.logger.Debug("Successfully retrieved pod versions", "url", podURL, "count", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"trunk": c.trunkURL},
+	}, nil
+}
@@ -0,0 +1,34 @@
+package deps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCocoaPodsSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/pods/AFNetworking" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"versions": [
+			{"name": "4.0.0", "created_at": "2020-04-19 18:31:09 UTC"},
+			{"name": "3.2.1", "created_at": "2018-05-04 14:12:25 UTC"}
+		]}`))
+	}))
+	defer server.Close()
+
+	// Pod names are case-sensitive and subspecs share the versions of their pod
+	resp, err := NewCocoaPodsSource(server.URL, nil, nil).GetVersions(context.Background(), "pkg:cocoapods/AFNetworking@3.2.1#Reachability")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(resp.Versions))
+	}
+	if first := resp.Versions[0].Version; first.Version != "3.2.1" || first.PublishedAt != "2018-05-04T14:12:25Z" {
+		t.Errorf("unexpected oldest version %+v", first)
+	}
+}
@@ -0,0 +1,127 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// DefaultPackagistURL is the metadata repository of packagist.org
+const DefaultPackagistURL = "https://repo.packagist.org"
+
+// packagistUnset marks a key removed relative to the previous version in minified metadata
+const packagistUnset = "__unset"
+
+// packagistVersion is the subset of a version entry in Composer v2 metadata
+type packagistVersion struct {
+	Version string `json:"version"`
+	Time    string `json:"time"`
+}
+
+// packagistPackage is a Composer v2 metadata file (p2/<vendor>/<name>.json)
+type packagistPackage struct {
+	Packages map[string][]packagistVersion `json:"packages"`
+}
+
+// ComposerSource reads tagged releases from Packagist or a compatible Composer repository
+// such as Private Packagist or Satis
+type ComposerSource struct {
+	repositoryURL string
+	httpClient    *http.Client
+	logger        *slog.Logger
+}
+
+// NewComposerSource creates a source for the Composer v2 repository at repositoryURL,
+// defaulting to packagist.org. A nil httpClient uses default settings.
+func NewComposerSource(repositoryURL string, httpClient *http.Client, logger *slog.Logger) *ComposerSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if repositoryURL == "" {
+		repositoryURL = DefaultPackagistURL
+	}
+
+	return &ComposerSource{
+		repositoryURL: strings.TrimSuffix(repositoryURL, "/"),
+		httpClient:    defaultHTTPClient(httpClient),
+		logger:        logger,
+	}
+}
+
+// Name identifies Packagist as a version source
+func (c *ComposerSource) Name() string {
+	return "composer"
+}
+
+// ServesRepository reports whether repositoryURL is the configured repository
+func (c *ComposerSource) ServesRepository(purlType, repositoryURL string) bool {
+	return purlType == packageurl.TypeComposer && sameRepository(c.repositoryURL, repositoryURL)
+}
+
+// GetVersions returns the tagged releases of a Composer package. Branch versions such as
+// dev-main or 2.x-dev are skipped since they are not releases.
+func (c *ComposerSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeComposer {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+	if purl.Namespace == "" {
+		return nil, fmt.Errorf("%w %q: composer package has no vendor", ErrInvalidPURL, rawPURL)
+	}
+
+	name := purl.Namespace + "/" + purl.Name
+	metadataURL := fmt.Sprintf("%s/p2/%s/%s.json", c.repositoryURL, url.PathEscape(purl.Namespace), url.PathEscape(purl.Name))
+	c.logger.Debug("Fetching composer metadata", "purl", rawPURL, "url", metadataURL)
+
+	var metadata packagistPackage
+	if err := fetchJSON(ctx, c.httpClient, c.logger, metadataURL, nil, &metadata); err != nil {
+		return nil, err
+	}
+
+	entries, ok := metadata.Packages[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s missing from metadata", ErrPackageNotFound, name)
+	}
+
+	versions := make([]VersionsAPIResponse, 0, len(entries))
+	var published string
+	for _, entry := range entries {
+		// Minified metadata omits keys that equal those of the previous version
+		switch entry.Time {
+		case "":
+		case packagistUnset:
+			published = ""
+		default:
+			published = entry.Time
+		}
+
+		if isComposerBranch(entry.Version) {
+			continue
+		}
+		versions = append(versions, VersionsAPIResponse{Version: Version{Version: entry.Version, PublishedAt: published}})
+	}
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	c.logger.Debug("Successfully retrieved composer versions", "url", metadataURL, "count", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"repository": c.repositoryURL},
+	}, nil
+}
+
+// isComposerBranch reports whether a Composer version refers to a branch instead of a tag
+func isComposerBranch(version string) bool {
+	return strings.HasPrefix(version, "dev-") || strings.HasSuffix(version, "-dev")
+}
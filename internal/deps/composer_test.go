@@ -0,0 +1,52 @@
+package deps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testPackagistMetadata is minified Composer v2 metadata: 1.0.0 inherits the time of 1.0.1
+const testPackagistMetadata = `{
+  "packages": {
+    "acme/lib": [
+      {"version": "2.x-dev", "time": "2024-03-01T00:00:00+00:00"},
+      {"version": "v2.0.0", "time": "2024-02-01T00:00:00+00:00"},
+      {"version": "v1.0.1", "time": "2023-06-01T00:00:00+00:00"},
+      {"version": "v1.0.0"}
+    ]
+  }
+}`
+
+func TestComposerSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/p2/acme/lib.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(testPackagistMetadata))
+	}))
+	defer server.Close()
+
+	source := NewComposerSource(server.URL+"/", nil, nil)
+	resp, err := source.GetVersions(context.Background(), "pkg:composer/Acme/Lib@v1.0.0")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	byVersion := make(map[string]Version)
+	for _, v := range resp.Versions {
+		byVersion[v.Version.Version] = v.Version
+	}
+	if len(byVersion) != 3 {
+		t.Fatalf("expected 3 tagged releases, got %v", byVersion)
+	}
+	if byVersion["v1.0.0"].PublishedAt != "2023-06-01T00:00:00+00:00" {
+		t.Errorf("expected v1.0.0 to inherit the time of the previous entry, got %q", byVersion["v1.0.0"].PublishedAt)
+	}
+
+	if _, err := source.GetVersions(context.Background(), "pkg:composer/acme/missing@1.0.0"); err == nil {
+		t.Error("expected error for unknown package")
+	}
+}
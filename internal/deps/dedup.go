@@ -0,0 +1,114 @@
+package deps
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flightCall is an in-flight or completed lookup
+type flightCall[T any] struct {
+	done chan struct{}
+	resp T
+	err  error
+}
+
+// flightGroup deduplicates lookups: concurrent callers for the same key share one call, and
+// successful results are kept so later callers do not repeat the request. Failed calls are
+// forgotten so that they can be retried.
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[T]
+}
+
+// do returns the result for key, calling fn only if no call for key is in flight or has
+// succeeded before. shared reports whether the result came from another caller.
+func (g *flightGroup[T]) do(ctx context.Context, key string, fn func() (T, error)) (resp T, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[T])
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.resp, true, call.err
+		case <-ctx.Done():
+			return resp, true, ctx.Err()
+		}
+	}
+
+	call := &flightCall[T]{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.resp, call.err = fn()
+	if call.err != nil {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+	}
+	close(call.done)
+
+	return call.resp, false, call.err
+}
+
+// has reports whether a call for key is in flight or has succeeded before
+func (g *flightGroup[T]) has(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.calls[key]
+	return ok
+}
+
+// store records resp as the successful result for key unless a call for key already exists
+func (g *flightGroup[T]) store(key string, resp T) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall[T])
+	}
+	if _, ok := g.calls[key]; ok {
+		return
+	}
+	call := &flightCall[T]{done: make(chan struct{}), resp: resp}
+	close(call.done)
+	g.calls[key] = call
+}
+
+// rateLimiter spaces requests evenly to stay below a requests-per-second limit
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newRateLimiter creates a limiter for the given rate; a rate <= 0 returns nil, which never waits
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / requestsPerSecond)}
+}
+
+// wait blocks until the caller may send the next request
+func (r *rateLimiter) wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	slot := r.next
+	if slot.Before(now) {
+		slot = now
+	}
+	r.next = slot.Add(r.interval)
+	r.mu.Unlock()
+
+	if delay := slot.Sub(now); delay > 0 {
+		return sleepContext(ctx, delay)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package deps
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Git object types in packfiles
+const (
+	gitObjectCommit   = 1
+	gitObjectTag      = 4
+	gitObjectOfsDelta = 6
+	gitObjectRefDelta = 7
+)
+
+// gitRef is a ref advertised by ls-refs; peeled is the commit an annotated tag points to
+type gitRef struct {
+	oid    string
+	name   string
+	peeled string
+}
+
+// pktLine encodes a git pkt-line
+func pktLine(line string) string {
+	return fmt.Sprintf("%04x%s", len(line)+4, line)
+}
+
+// gitCommandRequest builds a protocol v2 command request with the given arguments
+func gitCommandRequest(command string, args ...string) []byte {
+	var b strings.Builder
+	b.WriteString(pktLine("command=" + command + "\n"))
+	b.WriteString("0001")
+	for _, arg := range args {
+		b.WriteString(pktLine(arg + "\n"))
+	}
+	b.WriteString("0000")
+	return []byte(b.String())
+}
+
+// readPktLine reads one pkt-line. Flush, delimiter and response-end packets are returned
+// as special packets with nil payload.
+func readPktLine(r *bufio.Reader) (payload []byte, special string, err error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, "", err
+	}
+	length, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid pkt-line length %q", header)
+	}
+	if length < 4 {
+		return nil, string(header[:]), nil
+	}
+
+	payload = make([]byte, length-4)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, "", err
+	}
+	if bytes.HasPrefix(payload, []byte("ERR ")) {
+		return nil, "", fmt.Errorf("git server error: %s", strings.TrimSpace(string(payload[4:])))
+	}
+	return payload, "", nil
+}
+
+// parseLsRefs reads an ls-refs response
+func parseLsRefs(r io.Reader) ([]gitRef, error) {
+	br := bufio.NewReader(r)
+	var refs []gitRef
+	for {
+		payload, special, err := readPktLine(br)
+		if err != nil {
+			return nil, err
+		}
+		if payload == nil {
+			if special == "0000" {
+				return refs, nil
+			}
+			continue
+		}
+
+		fields := strings.Fields(string(payload))
+		if len(fields) < 2 {
+			continue
+		}
+		ref := gitRef{oid: fields[0], name: fields[1]}
+		for _, attribute := range fields[2:] {
+			if peeled, ok := strings.CutPrefix(attribute, "peeled:"); ok {
+				ref.peeled = peeled
+			}
+		}
+		refs = append(refs, ref)
+	}
+}
+
+// readFetchPackfile reads a protocol v2 fetch response and returns the packfile carried in
+// side-band channel 1
+func readFetchPackfile(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	var pack bytes.Buffer
+	inPackfile := false
+	for {
+		payload, special, err := readPktLine(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) && inPackfile {
+				return pack.Bytes(), nil
+			}
+			return nil, err
+		}
+		if payload == nil {
+			if special == "0000" && inPackfile {
+				return pack.Bytes(), nil
+			}
+			continue
+		}
+
+		if !inPackfile {
+			inPackfile = string(payload) == "packfile\n"
+			continue
+		}
+		switch payload[0] {
+		case 1:
+			pack.Write(payload[1:])
+		case 3:
+			return nil, fmt.Errorf("git server error: %s", strings.TrimSpace(string(payload[1:])))
+		}
+	}
+}
+
+// readPackObjectDates returns the dates of the commit and tag objects of a packfile by
+// object ID. Committer dates are used for commits, tagger dates for annotated tags.
+// Deltified objects are skipped since commits and tags are rarely stored as deltas.
+func readPackObjectDates(pack []byte) (map[string]time.Time, error) {
+	if len(pack) < 12 || string(pack[:4]) != "PACK" {
+		return nil, errors.New("invalid packfile header")
+	}
+	count := binary.BigEndian.Uint32(pack[8:12])
+	r := bytes.NewReader(pack[12:])
+
+	dates := make(map[string]time.Time)
+	for range count {
+		objectType, err := readPackObjectHeader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch objectType {
+		case gitObjectOfsDelta:
+			for {
+				b, err := r.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+				if b&0x80 == 0 {
+					break
+				}
+			}
+		case gitObjectRefDelta:
+			if _, err := r.Seek(sha1.Size, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+
+		// bytes.Reader is an io.ByteReader, so zlib does not read beyond the object
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packfile object: %w", err)
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packfile object: %w", err)
+		}
+
+		var typeName, dateField string
+		switch objectType {
+		case gitObjectCommit:
+			typeName, dateField = "commit", "committer "
+		case gitObjectTag:
+			typeName, dateField = "tag", "tagger "
+		default:
+			continue
+		}
+
+		hash := sha1.New()
+		fmt.Fprintf(hash, "%s %d\x00", typeName, len(data))
+		hash.Write(data)
+		if date, ok := gitObjectDate(data, dateField); ok {
+			dates[hex.EncodeToString(hash.Sum(nil))] = date
+		}
+	}
+
+	return dates, nil
+}
+
+// readPackObjectHeader reads the type and size header of a packfile object and returns the type
+func readPackObjectHeader(r io.ByteReader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	objectType := int(b>>4) & 0x7
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return objectType, nil
+}
+
+// gitObjectDate reads the timestamp of the identity line starting with field, e.g.
+// "committer Jane <jane@example.com> 1700000000 +0100"
+func gitObjectDate(data []byte, field string) (time.Time, bool) {
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if line == "" {
+			break // end of headers
+		}
+		identity, ok := strings.CutPrefix(line, field)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(identity)
+		if len(fields) < 2 {
+			return time.Time{}, false
+		}
+		seconds, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(seconds, 0).UTC(), true
+	}
+	return time.Time{}, false
+}
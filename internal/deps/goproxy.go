@@ -0,0 +1,448 @@
+package deps
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/package-url/packageurl-go"
+)
+
+const (
+	// DefaultGoProxy is the GOPROXY value used by the go command when the variable is unset
+	DefaultGoProxy = "https://proxy.golang.org,direct"
+	// goProxyInfoWorkers bounds the concurrent .info requests per module
+	goProxyInfoWorkers = 4
+)
+
+// ErrDirectModuleFetch is returned for modules the go command would fetch directly from
+// version control, which this source cannot do
+var ErrDirectModuleFetch = errors.New("module must be fetched directly from version control")
+
+// GoProxyConfig holds the go environment settings that decide how modules are fetched
+type GoProxyConfig struct {
+	GOPROXY   string
+	GONOPROXY string
+	GOPRIVATE string
+}
+
+// GoProxyConfigFromEnv reads the module proxy settings from the environment
+func GoProxyConfigFromEnv() GoProxyConfig {
+	return GoProxyConfig{
+		GOPROXY:   os.Getenv("GOPROXY"),
+		GONOPROXY: os.Getenv("GONOPROXY"),
+		GOPRIVATE: os.Getenv("GOPRIVATE"),
+	}
+}
+
+// goProxyEntry is one element of the GOPROXY list
+type goProxyEntry struct {
+	url string
+	// fallBackOnError is true if the entry was followed by "|", so any error moves on to
+	// the next proxy; after "," only not-found responses do
+	fallBackOnError bool
+}
+
+// goModuleInfo is the response of the /@v/<version>.info endpoint
+type goModuleInfo struct {
+	Version string `json:"Version"`
+	Time    string `json:"Time"`
+}
+
+// GoProxySource reads module versions from Go module proxies such as proxy.golang.org or Athens
+type GoProxySource struct {
+	proxies    []goProxyEntry
+	noProxy    string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewGoProxySource creates a module proxy source following the go command's GOPROXY,
+// GONOPROXY and GOPRIVATE semantics. A nil httpClient uses default settings.
+func NewGoProxySource(config GoProxyConfig, httpClient *http.Client, logger *slog.Logger) (*GoProxySource, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	proxies, err := parseGoProxy(config.GOPROXY)
+	if err != nil {
+		return nil, err
+	}
+
+	// As in the go command, GONOPROXY defaults to GOPRIVATE
+	noProxy := config.GONOPROXY
+	if noProxy == "" {
+		noProxy = config.GOPRIVATE
+	}
+
+	return &GoProxySource{
+		proxies:    proxies,
+		noProxy:    noProxy,
+		httpClient: defaultHTTPClient(httpClient),
+		logger:     logger,
+	}, nil
+}
+
+// parseGoProxy splits a GOPROXY value into its proxy URLs. "direct" ends the list since
+// version control access is not supported; "off" disables module downloads entirely.
+func parseGoProxy(value string) ([]goProxyEntry, error) {
+	if strings.TrimSpace(value) == "" {
+		value = DefaultGoProxy
+	}
+
+	var entries []goProxyEntry
+	for value != "" {
+		var element string
+		fallBack := false
+		if i := strings.IndexAny(value, ",|"); i >= 0 {
+			element, fallBack, value = value[:i], value[i] == '|', value[i+1:]
+		} else {
+			element, value = value, ""
+		}
+
+		element = strings.TrimSpace(element)
+		switch element {
+		case "":
+			continue
+		case "direct", "off":
+			if len(entries) == 0 {
+				return nil, fmt.Errorf("GOPROXY=%s contains no module proxy", element)
+			}
+			return entries, nil
+		}
+
+		entries = append(entries, goProxyEntry{url: strings.TrimSuffix(element, "/"), fallBackOnError: fallBack})
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.New("GOPROXY contains no module proxy")
+	}
+
+	return entries, nil
+}
+
+// Name identifies the module proxy as a version source
+func (g *GoProxySource) Name() string {
+	return "goproxy"
+}
+
+// GetVersions lists the tagged versions of a Go module and reads their publication times
+func (g *GoProxySource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeGolang {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	modulePath, err := goModulePath(rawPURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if matchGlobPatterns(g.noProxy, modulePath) {
+		g.logger.Debug("Module excluded from proxy by GONOPROXY/GOPRIVATE", "module", modulePath)
+		return nil, fmt.Errorf("%w: %s", ErrDirectModuleFetch, modulePath)
+	}
+
+	escapedPath, err := escapeModulePath(modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// A proxy declared in the PURL is the only one asked
+	proxies := g.proxies
+	if declared := purlRepositoryURL(purl); declared != "" {
+		if i := slices.IndexFunc(proxies, func(p goProxyEntry) bool { return sameRepository(p.url, declared) }); i >= 0 {
+			proxies = proxies[i : i+1]
+		}
+	}
+
+	var errs []error
+	for _, proxy := range proxies {
+		resp, err := g.getVersionsFromProxy(ctx, proxy.url, escapedPath)
+		if err == nil {
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", proxy.url, err))
+		if !proxy.fallBackOnError && !errors.Is(err, ErrPackageNotFound) {
+			break
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// ServesRepository reports whether repositoryURL is one of the module proxies
+func (g *GoProxySource) ServesRepository(purlType, repositoryURL string) bool {
+	return purlType == packageurl.TypeGolang && slices.ContainsFunc(g.proxies, func(p goProxyEntry) bool {
+		return sameRepository(p.url, repositoryURL)
+	})
+}
+
+// getVersionsFromProxy queries a single proxy for the version list and version infos
+func (g *GoProxySource) getVersionsFromProxy(ctx context.Context, proxyURL, escapedPath string) (*APIResponse, error) {
+	listURL := proxyURL + "/" + escapedPath + "/@v/list"
+	g.logger.Debug("Fetching module version list", "url", listURL)
+
+	var rawVersions []string
+	err := fetch(ctx, g.httpClient, g.logger, listURL, nil, func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if v := strings.TrimSpace(scanner.Text()); v != "" {
+				rawVersions = append(rawVersions, v)
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]VersionsAPIResponse, len(rawVersions))
+	forEachConcurrently(goProxyInfoWorkers, len(rawVersions), func(i int) {
+		versions[i] = VersionsAPIResponse{Version: g.getVersionInfo(ctx, proxyURL, escapedPath, rawVersions[i])}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if latest := latestGoVersion(rawVersions); latest != nil {
+		retractions := g.getRetractions(ctx, proxyURL, escapedPath, latest.Original())
+		for i := range versions {
+			versions[i].Version.Retracted = isRetracted(retractions, versions[i].Version.Version)
+		}
+	}
+
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	g.logger.Debug("Successfully retrieved module versions", "url", listURL, "count", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"proxy": proxyURL},
+	}, nil
+}
+
+// getVersionInfo reads the publication time of a version; failures leave the time empty
+func (g *GoProxySource) getVersionInfo(ctx context.Context, proxyURL, escapedPath, version string) Version {
+	result := Version{Version: version}
+
+	escapedVersion, err := escapeModulePath(version)
+	if err != nil {
+		g.logger.Debug("Skipping version info", "version", version, "error", err)
+		return result
+	}
+
+	var info goModuleInfo
+	infoURL := proxyURL + "/" + escapedPath + "/@v/" + escapedVersion + ".info"
+	if err := fetchJSON(ctx, g.httpClient, g.logger, infoURL, nil, &info); err != nil {
+		g.logger.Debug("Failed to fetch version info", "url", infoURL, "error", err)
+		return result
+	}
+
+	result.PublishedAt = info.Time
+	return result
+}
+
+// goRetraction is a closed version interval retracted by a retract directive
+type goRetraction struct {
+	low, high *version.Version
+}
+
+// latestGoVersion returns the version whose go.mod declares the retractions of a module: the
+// highest release, or the highest pre-release if there are no releases
+func latestGoVersion(rawVersions []string) *version.Version {
+	var latest *version.Version
+	for _, raw := range rawVersions {
+		v, err := version.NewSemver(raw)
+		if err != nil {
+			continue
+		}
+		isRelease := v.Prerelease() == ""
+		switch {
+		case latest == nil, isRelease && latest.Prerelease() != "":
+			latest = v
+		case isRelease == (latest.Prerelease() == "") && v.GreaterThan(latest):
+			latest = v
+		}
+	}
+	return latest
+}
+
+// getRetractions reads the retract directives from the go.mod of the latest version. As with
+// version infos, failures are only logged and no version is considered retracted.
+func (g *GoProxySource) getRetractions(ctx context.Context, proxyURL, escapedPath, latest string) []goRetraction {
+	escapedVersion, err := escapeModulePath(latest)
+	if err != nil {
+		g.logger.Debug("Skipping retractions", "version", latest, "error", err)
+		return nil
+	}
+
+	var retractions []goRetraction
+	modURL := proxyURL + "/" + escapedPath + "/@v/" + escapedVersion + ".mod"
+	err = fetch(ctx, g.httpClient, g.logger, modURL, nil, func(r io.Reader) error {
+		retractions, err = parseGoModRetractions(r)
+		return err
+	})
+	if err != nil {
+		g.logger.Debug("Failed to fetch retractions", "url", modURL, "error", err)
+		return nil
+	}
+
+	return retractions
+}
+
+// parseGoModRetractions extracts single versions and [low, high] intervals from the retract
+// directives of a go.mod file, both as single lines and in blocks
+func parseGoModRetractions(r io.Reader) ([]goRetraction, error) {
+	var retractions []goRetraction
+	inBlock := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		line = strings.TrimSpace(line)
+
+		var spec string
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case inBlock:
+			spec = line
+		case strings.HasPrefix(line, "retract ") || strings.HasPrefix(line, "retract\t") || strings.HasPrefix(line, "retract("):
+			spec = strings.TrimSpace(strings.TrimPrefix(line, "retract"))
+			if spec == "(" {
+				inBlock = true
+				continue
+			}
+		default:
+			continue
+		}
+		if spec == "" {
+			continue
+		}
+
+		low, high := spec, spec
+		if interval, ok := strings.CutPrefix(spec, "["); ok {
+			interval, ok = strings.CutSuffix(interval, "]")
+			if !ok {
+				return nil, fmt.Errorf("invalid retraction interval %q", spec)
+			}
+			low, high, ok = strings.Cut(interval, ",")
+			if !ok {
+				return nil, fmt.Errorf("invalid retraction interval %q", spec)
+			}
+		}
+
+		lowVersion, err := version.NewSemver(strings.Trim(strings.TrimSpace(low), `"`))
+		if err != nil {
+			return nil, fmt.Errorf("invalid retracted version %q: %w", low, err)
+		}
+		highVersion, err := version.NewSemver(strings.Trim(strings.TrimSpace(high), `"`))
+		if err != nil {
+			return nil, fmt.Errorf("invalid retracted version %q: %w", high, err)
+		}
+		retractions = append(retractions, goRetraction{low: lowVersion, high: highVersion})
+	}
+
+	return retractions, scanner.Err()
+}
+
+// isRetracted reports whether rawVersion lies in one of the retracted intervals
+func isRetracted(retractions []goRetraction, rawVersion string) bool {
+	v, err := version.NewSemver(rawVersion)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(retractions, func(r goRetraction) bool {
+		return !v.LessThan(r.low) && !v.GreaterThan(r.high)
+	})
+}
+
+// goModulePath extracts the module path from a golang PURL. The PURL parser lower-cases
+// golang namespaces and names, but proxies need the original case to resolve the module.
+func goModulePath(rawPURL string) (string, error) {
+	remainder, ok := strings.CutPrefix(rawPURL, "pkg:")
+	if !ok {
+		return "", fmt.Errorf("%w %q: missing pkg scheme", ErrInvalidPURL, rawPURL)
+	}
+	remainder = strings.TrimLeft(remainder, "/")
+	if i := strings.IndexAny(remainder, "@?#"); i >= 0 {
+		remainder = remainder[:i]
+	}
+
+	_, encodedPath, _ := strings.Cut(remainder, "/")
+	modulePath, err := url.PathUnescape(strings.Trim(encodedPath, "/"))
+	if err != nil || modulePath == "" {
+		return "", fmt.Errorf("%w %q: cannot extract module path", ErrInvalidPURL, rawPURL)
+	}
+
+	return modulePath, nil
+}
+
+// escapeModulePath applies the module proxy case-encoding: every upper-case letter is
+// replaced by an exclamation mark followed by the lower-case letter
+func escapeModulePath(modulePath string) (string, error) {
+	var b strings.Builder
+	for _, r := range modulePath {
+		switch {
+		case r == '!' || r >= 0x80:
+			return "", fmt.Errorf("invalid character %q in module path %q", r, modulePath)
+		case 'A' <= r && r <= 'Z':
+			b.WriteByte('!')
+			b.WriteRune(r + ('a' - 'A'))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+// matchGlobPatterns reports whether any of the comma-separated glob patterns matches a
+// prefix of the module path, using the same rules as GOPRIVATE
+func matchGlobPatterns(patterns, modulePath string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		// Match the pattern against the path prefix with the same number of elements
+		n := strings.Count(pattern, "/")
+		prefix := modulePath
+		for i := 0; i < len(modulePath); i++ {
+			if modulePath[i] == '/' {
+				if n == 0 {
+					prefix = modulePath[:i]
+					break
+				}
+				n--
+			}
+		}
+		if n > 0 {
+			continue
+		}
+
+		if matched, err := path.Match(pattern, prefix); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEscapeModulePath(t *testing.T) {
+	escaped, err := escapeModulePath("github.com/Azure/azure-sdk-for-go")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if escaped != "github.com/!azure/azure-sdk-for-go" {
+		t.Errorf("unexpected escaped path %q", escaped)
+	}
+}
+
+func TestMatchGlobPatterns(t *testing.T) {
+	testCases := []struct {
+		patterns string
+		module   string
+		expected bool
+	}{
+		{"git.acme.local", "git.acme.local/team/lib", true},
+		{"*.acme.local", "git.acme.local/team/lib", true},
+		{"git.acme.local/team", "git.acme.local/team/lib", true},
+		{"git.acme.local/other", "git.acme.local/team/lib", false},
+		{"github.com/acme/*,git.acme.local", "github.com/acme/tool", true},
+		{"github.com/acme/tool/sub", "github.com/acme/tool", false},
+		{"", "github.com/acme/tool", false},
+	}
+
+	for _, tc := range testCases {
+		if got := matchGlobPatterns(tc.patterns, tc.module); got != tc.expected {
+			t.Errorf("matchGlobPatterns(%q, %q) = %v, expected %v", tc.patterns, tc.module, got, tc.expected)
+		}
+	}
+}
+
+func TestGoProxySource(t *testing.T) {
+	athens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/git.acme.local/!team/lib/@v/list":
+			_, _ = w.Write([]byte("v1.0.0\nv1.1.0\n"))
+		case "/git.acme.local/!team/lib/@v/v1.0.0.info":
+			_, _ = w.Write([]byte(`{"Version":"v1.0.0","Time":"2023-01-01T00:00:00Z"}`))
+		case "/git.acme.local/!team/lib/@v/v1.1.0.info":
+			_, _ = w.Write([]byte(`{"Version":"v1.1.0","Time":"2023-03-01T00:00:00Z"}`))
+		case "/git.acme.local/!team/lib/@v/v1.1.0.mod":
+			_, _ = w.Write([]byte("module git.acme.local/Team/lib\n\nretract v1.0.0 // data loss\n"))
+		default:
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer athens.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer public.Close()
+
+	source, err := NewGoProxySource(GoProxyConfig{
+		GOPROXY:   public.URL + "," + athens.URL + ",direct",
+		GOPRIVATE: "github.com/acme",
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	resp, err := source.GetVersions(context.Background(), "pkg:golang/git.acme.local/Team/lib@v1.0.0")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(resp.Versions))
+	}
+	if v := resp.Versions[1].Version; v.Version != "v1.1.0" || v.PublishedAt != "2023-03-01T00:00:00Z" {
+		t.Errorf("unexpected newest version: %+v", v)
+	}
+	if !resp.Versions[0].Version.Retracted {
+		t.Errorf("expected v1.0.0 to be retracted by the go.mod of v1.1.0, got %+v", resp.Versions[0].Version)
+	}
+	if resp.Metadata["proxy"] != athens.URL {
+		t.Errorf("expected versions to come from the second proxy, got %q", resp.Metadata["proxy"])
+	}
+
+	_, err = source.GetVersions(context.Background(), "pkg:golang/github.com/acme/secret@v1.0.0")
+	if !errors.Is(err, ErrDirectModuleFetch) {
+		t.Errorf("expected GOPRIVATE module to be rejected, got: %v", err)
+	}
+
+	_, err = source.GetVersions(context.Background(), "pkg:golang/github.com/unknown/module@v1.0.0")
+	if !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("expected ErrPackageNotFound, got: %v", err)
+	}
+}
+
+func TestParseGoProxy(t *testing.T) {
+	entries, err := parseGoProxy("https://athens.acme.local|https://proxy.golang.org,direct")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(entries) != 2 || !entries[0].fallBackOnError || entries[1].fallBackOnError {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	if _, err := parseGoProxy("off"); err == nil {
+		t.Errorf("expected error for GOPROXY=off")
+	}
+}
+
+func TestParseGoModRetractions(t *testing.T) {
+	goMod := `module example.com/lib
+
+go 1.21
+
+retract v1.0.1 // published accidentally
+
+retract (
+	[v1.2.0, v1.2.3] // race in the cache
+	"v1.3.0"
+	// v1.4.0 is fine
+)
+
+require example.com/other v1.0.0
+`
+	retractions, err := parseGoModRetractions(strings.NewReader(goMod))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(retractions) != 3 {
+		t.Fatalf("expected 3 retractions, got %d", len(retractions))
+	}
+
+	for v, want := range map[string]bool{
+		"v1.0.0": false,
+		"v1.0.1": true,
+		"v1.2.0": true,
+		"v1.2.2": true,
+		"v1.2.4": false,
+		"v1.3.0": true,
+		"v1.4.0": false,
+	} {
+		if got := isRetracted(retractions, v); got != want {
+			t.Errorf("isRetracted(%s) = %v, want %v", v, got, want)
+		}
+	}
+
+	if _, err := parseGoModRetractions(strings.NewReader("retract [v1.0.0\n")); err == nil {
+		t.Error("expected error for an unterminated interval")
+	}
+}
+
+func TestLatestGoVersion(t *testing.T) {
+	if latest := latestGoVersion([]string{"v1.0.0", "v1.10.0", "v2.0.0-rc.1", "v1.9.0"}); latest.Original() != "v1.10.0" {
+		t.Errorf("expected the highest release, got %s", latest.Original())
+	}
+	if latest := latestGoVersion([]string{"v0.1.0-alpha", "v0.2.0-beta"}); latest.Original() != "v0.2.0-beta" {
+		t.Errorf("expected the highest pre-release without releases, got %s", latest.Original())
+	}
+}
@@ -0,0 +1,120 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// DefaultHexURL is the public Hex package manager for Erlang and Elixir
+const DefaultHexURL = "https://hex.pm"
+
+// hexPackage is the subset of the hex.pm API package response used for version histories
+type hexPackage struct {
+	Releases []struct {
+		Version    string `json:"version"`
+		InsertedAt string `json:"inserted_at"`
+	} `json:"releases"`
+	// Retirements maps retired releases to the reason given by the publisher
+	Retirements map[string]struct {
+		Reason string `json:"reason"`
+	} `json:"retirements"`
+}
+
+// HexSource reads release histories from the hex.pm HTTP API
+type HexSource struct {
+	apiURL     string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewHexSource creates a source for the Hex API at apiURL, defaulting to hex.pm.
+// A nil httpClient uses default settings.
+func NewHexSource(apiURL string, httpClient *http.Client, logger *slog.Logger) *HexSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if apiURL == "" {
+		apiURL = DefaultHexURL
+	}
+
+	return &HexSource{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		httpClient: defaultHTTPClient(httpClient),
+		logger:     logger,
+	}
+}
+
+// Name identifies Hex as a version source
+func (h *HexSource) Name() string {
+	return "hex"
+}
+
+// ServesRepository reports whether repositoryURL is the configured Hex instance
+func (h *HexSource) ServesRepository(purlType, repositoryURL string) bool {
+	return purlType == packageurl.TypeHex && sameRepository(h.apiURL, repositoryURL)
+}
+
+// GetVersions returns all releases of a Hex package. Hex requires strict SemVer 2.0, so
+// releases that are not valid semantic versions are skipped.
+func (h *HexSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeHex {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	packageURL := h.apiURL + "/api/packages/" + url.PathEscape(strings.ToLower(purl.Name))
+	h.logger.Debug("Fetching hex package", "purl", rawPURL, "url", packageURL)
+
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+
+	var pkg hexPackage
+	if err := fetchJSON(ctx, h.httpClient, h.logger, packageURL, header, &pkg); err != nil {
+		return nil, err
+	}
+
+	versions := make([]VersionsAPIResponse, 0, len(pkg.Releases))
+	for _, release := range pkg.Releases {
+		if !isStrictSemver(release.Version) {
+			h.logger.Debug("Skipping non-semver hex release", "version", release.Version)
+			continue
+		}
+		_, retired := pkg.Retirements[release.Version]
+		versions = append(versions, VersionsAPIResponse{Version: Version{
+			Version:     release.Version,
+			PublishedAt: release.InsertedAt,
+			Retracted:   retired,
+		}})
+	}
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	h.logger.Debug("Successfully retrieved hex versions", "url", packageURL, "count", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"repository": h.apiURL},
+	}, nil
+}
+
+// strictSemverPattern is the SemVer 2.0 grammar as published on semver.org
+var strictSemverPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// isStrictSemver reports whether version is a SemVer 2.0 version without prefix
+func isStrictSemver(version string) bool {
+	return strictSemverPattern.MatchString(version)
+}
@@ -0,0 +1,51 @@
+package deps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHexSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/packages/phoenix" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"releases": [
+			{"version": "1.7.10", "inserted_at": "2023-11-03T00:00:00.000000Z"},
+			{"version": "1.7.0-rc.0", "inserted_at": "2022-11-07T00:00:00.000000Z"},
+			{"version": "1.6", "inserted_at": "2021-08-26T00:00:00.000000Z"}
+		], "retirements": {"1.7.0-rc.0": {"reason": "invalid", "message": "broken release"}}}`))
+	}))
+	defer server.Close()
+
+	resp, err := NewHexSource(server.URL, nil, nil).GetVersions(context.Background(), "pkg:hex/phoenix@1.7.10")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 {
+		t.Fatalf("expected the non-semver release to be skipped, got %+v", resp.Versions)
+	}
+	if first := resp.Versions[0].Version; first.Version != "1.7.0-rc.0" || first.PublishedAt != "2022-11-07T00:00:00.000000Z" {
+		t.Errorf("unexpected first release %+v", first)
+	}
+	if !resp.Versions[0].Version.Retracted || resp.Versions[1].Version.Retracted {
+		t.Errorf("expected only the retired release to be retracted, got %+v", resp.Versions)
+	}
+}
+
+func TestIsStrictSemver(t *testing.T) {
+	for version, want := range map[string]bool{
+		"1.0.0":            true,
+		"1.0.0-rc.1+build": true,
+		"v1.0.0":           false,
+		"1.0":              false,
+		"01.0.0":           false,
+	} {
+		if got := isStrictSemver(version); got != want {
+			t.Errorf("isStrictSemver(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package deps
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/package-url/packageurl-go"
+)
+
+const (
+	// mavenTimestampLayout is the format of lastUpdated in maven-metadata.xml
+	mavenTimestampLayout = "20060102150405"
+	// mavenHeadWorkers bounds the concurrent artifact HEAD requests per package
+	mavenHeadWorkers = 4
+)
+
+// MavenRepository is a Maven repository with optional basic auth credentials
+type MavenRepository struct {
+	URL      string
+	Username string
+	Password string
+}
+
+// mavenMetadata is the subset of maven-metadata.xml used for version histories
+type mavenMetadata struct {
+	Versioning struct {
+		Latest      string   `xml:"latest"`
+		Release     string   `xml:"release"`
+		Versions    []string `xml:"versions>version"`
+		LastUpdated string   `xml:"lastUpdated"`
+	} `xml:"versioning"`
+}
+
+// MavenSource reads version histories from Maven repositories such as Nexus or Artifactory
+type MavenSource struct {
+	repositories []MavenRepository
+	httpClient   *http.Client
+	logger       *slog.Logger
+}
+
+// NewMavenSource creates a source querying the repositories in order. A nil httpClient uses default settings.
+func NewMavenSource(repositories []MavenRepository, httpClient *http.Client, logger *slog.Logger) *MavenSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &MavenSource{
+		repositories: repositories,
+		httpClient:   defaultHTTPClient(httpClient),
+		logger:       logger,
+	}
+}
+
+// Name identifies Maven repositories as a version source
+func (m *MavenSource) Name() string {
+	return "maven"
+}
+
+// GetVersions reads the version list from maven-metadata.xml of the first repository that
+// has the artifact. Publication dates come from the Last-Modified header of each version's
+// POM, falling back to the metadata lastUpdated timestamp for the latest release.
+func (m *MavenSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeMaven && purl.Type != packageurl.TypeGradle {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+	if purl.Namespace == "" {
+		return nil, fmt.Errorf("%w %q: maven package has no group id", ErrInvalidPURL, rawPURL)
+	}
+	if len(m.repositories) == 0 {
+		return nil, fmt.Errorf("%w: no maven repositories configured", ErrPackageNotFound)
+	}
+
+	artifactPath := strings.ReplaceAll(purl.Namespace, ".", "/") + "/" + purl.Name
+
+	// A repository declared in the PURL is the only one asked
+	repositories := m.repositories
+	if declared := purlRepositoryURL(purl); declared != "" {
+		if i := slices.IndexFunc(repositories, func(r MavenRepository) bool { return sameRepository(r.URL, declared) }); i >= 0 {
+			repositories = repositories[i : i+1]
+		}
+	}
+
+	var errs []error
+	for _, repository := range repositories {
+		resp, err := m.getVersionsFromRepository(ctx, repository, artifactPath, purl.Name)
+		if err == nil {
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", repository.URL, err))
+		if !errors.Is(err, ErrPackageNotFound) {
+			break
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// ServesRepository reports whether repositoryURL is one of the configured repositories
+func (m *MavenSource) ServesRepository(purlType, repositoryURL string) bool {
+	if purlType != packageurl.TypeMaven && purlType != packageurl.TypeGradle {
+		return false
+	}
+	return slices.ContainsFunc(m.repositories, func(r MavenRepository) bool {
+		return sameRepository(r.URL, repositoryURL)
+	})
+}
+
+// getVersionsFromRepository queries a single repository for an artifact
+func (m *MavenSource) getVersionsFromRepository(ctx context.Context, repository MavenRepository, artifactPath, artifactID string) (*APIResponse, error) {
+	baseURL := strings.TrimSuffix(repository.URL, "/") + "/" + artifactPath
+	metadataURL := baseURL + "/maven-metadata.xml"
+	m.logger.Debug("Fetching maven metadata", "url", metadataURL)
+
+	header := http.Header{}
+	if repository.Username != "" || repository.Password != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(repository.Username + ":" + repository.Password))
+		header.Set("Authorization", "Basic "+credentials)
+	}
+
+	var metadata mavenMetadata
+	err := fetch(ctx, m.httpClient, m.logger, metadataURL, header, func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(&metadata)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var lastUpdated string
+	if t, err := time.Parse(mavenTimestampLayout, metadata.Versioning.LastUpdated); err == nil {
+		lastUpdated = t.UTC().Format(time.RFC3339)
+	}
+	newest := metadata.Versioning.Release
+	if newest == "" {
+		newest = metadata.Versioning.Latest
+	}
+
+	rawVersions := metadata.Versioning.Versions
+	versions := make([]VersionsAPIResponse, len(rawVersions))
+	forEachConcurrently(mavenHeadWorkers, len(rawVersions), func(i int) {
+		v := Version{Version: rawVersions[i]}
+
+		pomURL := fmt.Sprintf("%s/%s/%s-%s.pom", baseURL, v.Version, artifactID, v.Version)
+		if respHeader, err := fetchHeader(ctx, m.httpClient, m.logger, pomURL, header); err != nil {
+			m.logger.Debug("Failed to read artifact headers", "url", pomURL, "error", err)
+		} else if t, err := http.ParseTime(respHeader.Get("Last-Modified")); err == nil {
+			v.PublishedAt = t.UTC().Format(time.RFC3339)
+		}
+
+		// The metadata was last updated when the newest release was deployed
+		if v.PublishedAt == "" && v.Version == newest {
+			v.PublishedAt = lastUpdated
+		}
+
+		versions[i] = VersionsAPIResponse{Version: v}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	m.logger.Debug("Successfully retrieved maven versions", "url", metadataURL, "count", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"repository": repository.URL},
+	}, nil
+}
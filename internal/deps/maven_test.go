@@ -0,0 +1,67 @@
+package deps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testMavenMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.acme</groupId>
+  <artifactId>lib</artifactId>
+  <versioning>
+    <latest>1.1.0</latest>
+    <release>1.1.0</release>
+    <versions>
+      <version>1.0.0</version>
+      <version>1.1.0</version>
+    </versions>
+    <lastUpdated>20230301120000</lastUpdated>
+  </versioning>
+</metadata>`
+
+func TestMavenSource(t *testing.T) {
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer empty.Close()
+
+	nexus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "ci" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/repository/releases/com/acme/lib/maven-metadata.xml":
+			_, _ = w.Write([]byte(testMavenMetadata))
+		case "/repository/releases/com/acme/lib/1.0.0/lib-1.0.0.pom":
+			w.Header().Set("Last-Modified", "Sun, 01 Jan 2023 10:00:00 GMT")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer nexus.Close()
+
+	source := NewMavenSource([]MavenRepository{
+		{URL: empty.URL},
+		{URL: nexus.URL + "/repository/releases/", Username: "ci", Password: "secret"},
+	}, nil, nil)
+
+	resp, err := source.GetVersions(context.Background(), "pkg:maven/com.acme/lib@1.0.0")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(resp.Versions))
+	}
+
+	first, second := resp.Versions[0].Version, resp.Versions[1].Version
+	if first.Version != "1.0.0" || first.PublishedAt != "2023-01-01T10:00:00Z" {
+		t.Errorf("expected Last-Modified as publication date, got %+v", first)
+	}
+	if second.Version != "1.1.0" || second.PublishedAt != "2023-03-01T12:00:00Z" {
+		t.Errorf("expected lastUpdated as publication date of the release, got %+v", second)
+	}
+}
@@ -0,0 +1,232 @@
+package deps
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// DefaultNpmRegistry is the public npm registry used when no registry is configured
+const DefaultNpmRegistry = "https://registry.npmjs.org"
+
+// NpmConfig configures an npm registry source, mirroring the relevant parts of .npmrc
+type NpmConfig struct {
+	// Registry is the default registry URL for unscoped and unmapped packages
+	Registry string
+	// ScopeRegistries maps scopes such as "@acme" to their registry URL
+	ScopeRegistries map[string]string
+	// AuthTokens maps registry prefixes in .npmrc notation ("//host/path/") to bearer tokens
+	AuthTokens map[string]string
+}
+
+// LoadNpmrc reads registry settings from an .npmrc file
+func LoadNpmrc(path string) (NpmConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return NpmConfig{}, fmt.Errorf("failed to open npmrc: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			slog.Default().Warn("Failed to close npmrc", "error", closeErr)
+		}
+	}()
+
+	return ParseNpmrc(file)
+}
+
+// ParseNpmrc extracts registry, scoped registry and auth token settings from .npmrc content.
+// Environment variables in the ${VAR} notation are expanded like npm does.
+func ParseNpmrc(r io.Reader) (NpmConfig, error) {
+	config := NpmConfig{
+		ScopeRegistries: make(map[string]string),
+		AuthTokens:      make(map[string]string),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = os.Expand(strings.Trim(strings.TrimSpace(value), `"'`), os.Getenv)
+
+		switch {
+		case key == "registry":
+			config.Registry = value
+		case strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry"):
+			config.ScopeRegistries[strings.TrimSuffix(key, ":registry")] = value
+		case strings.HasPrefix(key, "//") && strings.HasSuffix(key, ":_authToken"):
+			config.AuthTokens[strings.TrimSuffix(key, ":_authToken")] = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return NpmConfig{}, fmt.Errorf("failed to read npmrc: %w", err)
+	}
+
+	return config, nil
+}
+
+// npmPackument is the subset of an npm packument needed to build the version history
+type npmPackument struct {
+	Versions map[string]struct {
+		// Deprecated holds the deprecation message; registries may also send false or ""
+		Deprecated json.RawMessage `json:"deprecated"`
+	} `json:"versions"`
+	Time map[string]string `json:"time"`
+}
+
+// isNpmDeprecated reports whether a raw deprecated field marks the version as deprecated
+func isNpmDeprecated(raw json.RawMessage) bool {
+	switch strings.TrimSpace(string(raw)) {
+	case "", "null", "false", `""`:
+		return false
+	default:
+		return true
+	}
+}
+
+// NpmSource reads version histories directly from an npm registry, e.g. a private
+// Verdaccio or Artifactory instance that deps.dev cannot see
+type NpmSource struct {
+	config     NpmConfig
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewNpmSource creates an npm registry source. A nil httpClient uses default settings.
+func NewNpmSource(config NpmConfig, httpClient *http.Client, logger *slog.Logger) *NpmSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if config.Registry == "" {
+		config.Registry = DefaultNpmRegistry
+	}
+
+	return &NpmSource{
+		config:     config,
+		httpClient: defaultHTTPClient(httpClient),
+		logger:     logger,
+	}
+}
+
+// Name identifies the npm registry as a version source
+func (n *NpmSource) Name() string {
+	return "npm"
+}
+
+// GetVersions reads the packument of an npm package and returns its versions with publication times
+func (n *NpmSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeNPM {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	name := purl.Name
+	if purl.Namespace != "" {
+		name = purl.Namespace + "/" + purl.Name
+	}
+
+	registry := n.registryFor(purl.Namespace, purlRepositoryURL(purl))
+	packumentURL := registry + "/" + url.PathEscape(name)
+	n.logger.Debug("Fetching npm packument", "purl", rawPURL, "url", packumentURL)
+
+	header := http.Header{}
+	// The abbreviated metadata format omits the time field, so request the full document
+	header.Set("Accept", "application/json")
+	if token := n.tokenFor(registry); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	var packument npmPackument
+	if err := fetchJSON(ctx, n.httpClient, n.logger, packumentURL, header, &packument); err != nil {
+		return nil, err
+	}
+
+	versions := make([]VersionsAPIResponse, 0, len(packument.Versions))
+	for v, manifest := range packument.Versions {
+		versions = append(versions, VersionsAPIResponse{
+			Version: Version{Version: v, PublishedAt: packument.Time[v], Deprecated: isNpmDeprecated(manifest.Deprecated)},
+		})
+	}
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	n.logger.Debug("Successfully retrieved npm versions", "url", packumentURL, "count", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"registry": registry},
+	}, nil
+}
+
+// ServesRepository reports whether repositoryURL is the default or a scoped registry
+func (n *NpmSource) ServesRepository(purlType, repositoryURL string) bool {
+	return purlType == packageurl.TypeNPM && n.configuredRegistry(repositoryURL) != ""
+}
+
+// registryFor returns the registry declared in the PURL if it is configured, else the registry
+// responsible for the scope, falling back to the default registry
+func (n *NpmSource) registryFor(scope, declared string) string {
+	registry := n.config.Registry
+	if scoped, ok := n.config.ScopeRegistries[scope]; ok && scope != "" {
+		registry = scoped
+	}
+	if configured := n.configuredRegistry(declared); configured != "" {
+		registry = configured
+	}
+	return strings.TrimSuffix(registry, "/")
+}
+
+// configuredRegistry returns the configured registry matching repositoryURL, if any
+func (n *NpmSource) configuredRegistry(repositoryURL string) string {
+	if repositoryURL == "" {
+		return ""
+	}
+	if sameRepository(n.config.Registry, repositoryURL) {
+		return n.config.Registry
+	}
+	for _, registry := range n.config.ScopeRegistries {
+		if sameRepository(registry, repositoryURL) {
+			return registry
+		}
+	}
+	return ""
+}
+
+// tokenFor returns the auth token with the longest matching registry prefix
+func (n *NpmSource) tokenFor(registry string) string {
+	nerfed := registry + "/"
+	if i := strings.Index(nerfed, "//"); i >= 0 {
+		nerfed = nerfed[i:]
+	}
+
+	var token string
+	var longest int
+	for prefix, value := range n.config.AuthTokens {
+		if strings.HasPrefix(nerfed, prefix) && len(prefix) > longest {
+			token, longest = value, len(prefix)
+		}
+	}
+	return token
+}
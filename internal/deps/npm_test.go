@@ -0,0 +1,78 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testPackument = `{
+  "name": "@acme/lib",
+  "versions": {"1.0.0": {"deprecated": "use 1.1.0"}, "1.1.0": {"deprecated": false}},
+  "time": {
+    "created": "2021-01-01T00:00:00.000Z",
+    "modified": "2021-02-01T00:00:00.000Z",
+    "1.0.0": "2021-01-01T00:00:00.000Z",
+    "1.1.0": "2021-02-01T00:00:00.000Z"
+  }
+}`
+
+func TestNpmSourceScopedRegistry(t *testing.T) {
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.EscapedPath() != "/npm/@acme%2Flib" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(testPackument))
+	}))
+	defer private.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer public.Close()
+
+	npmrc := "registry=" + public.URL + "\n" +
+		"@acme:registry=" + private.URL + "/npm/\n" +
+		strings.TrimPrefix(private.URL, "http:") + "/npm/:_authToken=secret\n"
+	config, err := ParseNpmrc(strings.NewReader(npmrc))
+	if err != nil {
+		t.Fatalf("failed to parse npmrc: %v", err)
+	}
+
+	source := NewNpmSource(config, nil, nil)
+
+	resp, err := source.GetVersions(context.Background(), "pkg:npm/%40acme/lib@1.0.0")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(resp.Versions))
+	}
+	if resp.Versions[0].Version.Version != "1.0.0" || resp.Versions[0].Version.PublishedAt != "2021-01-01T00:00:00.000Z" {
+		t.Errorf("unexpected first version: %+v", resp.Versions[0].Version)
+	}
+	if !resp.Versions[0].Version.Deprecated || resp.Versions[1].Version.Deprecated {
+		t.Errorf("expected only 1.0.0 to be deprecated, got %+v", resp.Versions)
+	}
+	if _, err := resp.Versions[1].Version.Time(); err != nil {
+		t.Errorf("expected parsable publication date, got: %v", err)
+	}
+
+	_, err = source.GetVersions(context.Background(), "pkg:npm/left-pad@1.0.0")
+	if !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("expected unscoped package to go to the default registry and miss, got: %v", err)
+	}
+
+	_, err = source.GetVersions(context.Background(), "pkg:pypi/requests@2.0.0")
+	if !errors.Is(err, ErrUnsupportedPackageType) {
+		t.Errorf("expected ErrUnsupportedPackageType, got: %v", err)
+	}
+}
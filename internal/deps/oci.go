@@ -0,0 +1,521 @@
+package deps
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/package-url/packageurl-go"
+)
+
+const (
+	// ociDefaultRegistry is the registry of images without repository_url, like the docker CLI
+	ociDefaultRegistry = "docker.io"
+	// ociManifestWorkers bounds the concurrent manifest and config requests per image
+	ociManifestWorkers = 4
+	// DefaultOCIMaxDatedTags is the number of tags per image whose creation time is read by default
+	DefaultOCIMaxDatedTags = 25
+)
+
+// ociManifestTypes are the manifest media types accepted from registries
+var ociManifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// imageTagPattern splits a tag into a version of numeric segments and an optional variant,
+// e.g. "1.25.3-alpine3.19" into "1.25.3" and "alpine3.19"
+var imageTagPattern = regexp.MustCompile(`^(v?[0-9]+(?:\.[0-9]+)*)(?:[-_](.+))?$`)
+
+// OCIRegistry is a container registry with optional credentials. Images on registries that
+// are not configured are fetched anonymously over HTTPS.
+type OCIRegistry struct {
+	// URL is the registry base URL, e.g. https://ghcr.io or http://localhost:5000
+	URL      string
+	Username string
+	Password string
+}
+
+// OCIConfig configures the container registry source
+type OCIConfig struct {
+	Registries []OCIRegistry
+	// Retry bounds the retries of rate-limited and transient registry failures
+	Retry RetryPolicy
+	// RateLimit is the maximum number of registry requests per second; 0 means no limit
+	RateLimit float64
+	// MaxDatedTags caps the tags per image whose creation time is read; 0 uses DefaultOCIMaxDatedTags
+	MaxDatedTags int
+}
+
+// ociManifest is the subset of image manifests and indexes used to find the image config
+type ociManifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// OCISource lists image tags through the OCI distribution API. Tags with a version are the
+// releases of an image, dated by the created timestamp of their image config.
+type OCISource struct {
+	registries   []OCIRegistry
+	retry        RetryPolicy
+	limiter      *rateLimiter
+	maxDatedTags int
+	httpClient   *http.Client
+	logger       *slog.Logger
+
+	mu     sync.Mutex
+	tokens map[string]string
+
+	requests      atomic.Int64
+	retries       atomic.Int64
+	retriedImages sync.Map
+}
+
+// NewOCISource creates a source for container images. A nil httpClient uses default settings.
+func NewOCISource(config OCIConfig, httpClient *http.Client, logger *slog.Logger) *OCISource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &OCISource{
+		registries:   config.Registries,
+		retry:        config.Retry,
+		limiter:      newRateLimiter(config.RateLimit),
+		maxDatedTags: cmp.Or(config.MaxDatedTags, DefaultOCIMaxDatedTags),
+		httpClient:   defaultHTTPClient(httpClient),
+		logger:       logger,
+		tokens:       make(map[string]string),
+	}
+}
+
+// Name identifies container registries as a version source
+func (o *OCISource) Name() string {
+	return "oci"
+}
+
+// ServesRepository reports true for all images since any registry can be queried directly
+func (o *OCISource) ServesRepository(purlType, _ string) bool {
+	return purlType == packageurl.TypeOCI || purlType == packageurl.TypeDocker
+}
+
+// ImageReference returns the registry host, the repository and the tag of a pkg:oci or
+// pkg:docker PURL. The tag is taken from the tag qualifier or, for docker PURLs, the version
+// unless it is a digest.
+func ImageReference(purl packageurl.PackageURL) (registry, repository, tag string, err error) {
+	qualifiers := purl.Qualifiers.Map()
+	tag = qualifiers["tag"]
+
+	switch purl.Type {
+	case packageurl.TypeOCI:
+		location := normalizeRepositoryURL(qualifiers[repositoryQualifier])
+		if location == "" {
+			location = ociDefaultRegistry + "/" + purl.Name
+		}
+		registry, repository, _ = strings.Cut(location, "/")
+	case packageurl.TypeDocker:
+		registry, _, _ = strings.Cut(normalizeRepositoryURL(qualifiers[repositoryQualifier]), "/")
+		repository = purl.Name
+		if purl.Namespace != "" {
+			repository = purl.Namespace + "/" + purl.Name
+		}
+		if tag == "" && !strings.Contains(purl.Version, ":") {
+			tag = purl.Version
+		}
+	default:
+		return "", "", "", fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	registry = canonicalRegistry(registry)
+	if registry == ociDefaultRegistry && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	if repository == "" {
+		return "", "", "", fmt.Errorf("%w: image has no repository", ErrInvalidPURL)
+	}
+
+	return registry, repository, tag, nil
+}
+
+// canonicalRegistry maps the aliases of Docker Hub and an empty host to docker.io
+func canonicalRegistry(host string) string {
+	switch host {
+	case "", "hub.docker.com", "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return ociDefaultRegistry
+	default:
+		return host
+	}
+}
+
+// SplitImageTag splits a tag into its version and variant. ok is false for tags without a
+// version, such as "latest" or "bookworm".
+func SplitImageTag(tag string) (version, variant string, ok bool) {
+	match := imageTagPattern.FindStringSubmatch(tag)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// GetVersions lists the tags of an image that share the used tag's variant and precision,
+// e.g. "1.25.4-alpine" for "1.25.3-alpine" but neither "1.25-alpine" nor "1.25.4". The
+// floating tags of a release line and other variants are thereby not counted as releases.
+// Only the used tag and newer ones are reported, since reading a tag's creation time takes
+// up to three requests; beyond the configured maximum the oldest newer tags are reported
+// without a date, so they still count as missed releases.
+// Versions are reported without the variant.
+func (o *OCISource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	registry, repository, usedTag, err := ImageReference(purl)
+	if err != nil {
+		return nil, err
+	}
+
+	usedVersion, usedVariant, ok := SplitImageTag(usedTag)
+	if !ok {
+		return nil, fmt.Errorf("%w: tag %q of %s has no version", ErrPackageNotFound, usedTag, repository)
+	}
+	precision := strings.Count(usedVersion, ".")
+
+	baseURL, creds := o.registryFor(registry)
+	tags, err := o.listTags(ctx, baseURL, creds, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []string
+	for _, tag := range tags {
+		version, variant, ok := SplitImageTag(tag)
+		if ok && variant == usedVariant && strings.Count(version, ".") == precision &&
+			compareTagVersions(version, usedVersion) >= 0 {
+			matching = append(matching, tag)
+		}
+	}
+	slices.SortFunc(matching, func(a, b string) int {
+		versionA, _, _ := SplitImageTag(a)
+		versionB, _, _ := SplitImageTag(b)
+		return compareTagVersions(versionA, versionB)
+	})
+	// The used tag and the newest tags are dated, the tags in between only beyond the cap
+	undated := max(len(matching)-o.maxDatedTags, 0)
+	if undated > 0 {
+		o.logger.Warn("Image has more newer tags than are dated, leaving the oldest undated",
+			"repository", repository, "tags", len(matching), "dated", o.maxDatedTags)
+	}
+
+	versions := make([]VersionsAPIResponse, len(matching))
+	var failed, rateLimited atomic.Int64
+	forEachConcurrently(ociManifestWorkers, len(matching), func(i int) {
+		version, _, _ := SplitImageTag(matching[i])
+		versions[i] = VersionsAPIResponse{Version: Version{Version: version}}
+		if i > 0 && i <= undated {
+			return
+		}
+		created, err := o.imageCreated(ctx, baseURL, creds, repository, matching[i])
+		if err != nil {
+			o.logger.Debug("Failed to read image creation time", "repository", repository, "tag", matching[i], "error", err)
+			failed.Add(1)
+			if errors.Is(err, ErrRateLimited) {
+				rateLimited.Add(1)
+			}
+			return
+		}
+		versions[i].Version.PublishedAt = created
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if failed.Load() > 0 {
+		o.logger.Warn("Failed to read the creation time of image tags",
+			"repository", repository, "failed", failed.Load(), "rate_limited", rateLimited.Load())
+	}
+
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+	o.logger.Debug("Successfully retrieved image tags", "registry", registry, "repository", repository, "tags", len(tags), "matching", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"registry": registry, "variant": usedVariant},
+	}, nil
+}
+
+// compareTagVersions orders the numeric versions of two tags segment by segment
+func compareTagVersions(a, b string) int {
+	segmentsA := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	segmentsB := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := range min(len(segmentsA), len(segmentsB)) {
+		numberA, _ := strconv.ParseInt(segmentsA[i], 10, 64)
+		numberB, _ := strconv.ParseInt(segmentsB[i], 10, 64)
+		if c := cmp.Compare(numberA, numberB); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(segmentsA), len(segmentsB))
+}
+
+// host returns the lowercased host of the registry URL, docker.io for Docker Hub
+func (o *OCIRegistry) host() string {
+	host, _, _ := strings.Cut(normalizeRepositoryURL(o.URL), "/")
+	return canonicalRegistry(host)
+}
+
+// registryFor returns the base URL and the configured credentials of a registry host
+func (o *OCISource) registryFor(registry string) (string, *OCIRegistry) {
+	for i := range o.registries {
+		if o.registries[i].host() == registry {
+			return strings.TrimSuffix(o.registries[i].URL, "/"), &o.registries[i]
+		}
+	}
+	if registry == ociDefaultRegistry {
+		return "https://registry-1.docker.io", nil
+	}
+	return "https://" + registry, nil
+}
+
+// listTags reads all tags of a repository, following Link headers of paginated responses
+func (o *OCISource) listTags(ctx context.Context, baseURL string, creds *OCIRegistry, repository string) ([]string, error) {
+	var tags []string
+	next := baseURL + "/v2/" + repository + "/tags/list?n=1000"
+	for next != "" {
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		header, err := o.get(ctx, creds, repository, next, "application/json", func(r io.Reader) error {
+			return json.NewDecoder(r).Decode(&page)
+		})
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Tags...)
+
+		next = ""
+		if link := nextLink(header.Get("Link")); link != "" {
+			if ref, err := url.Parse(link); err == nil {
+				base, _ := url.Parse(baseURL)
+				next = base.ResolveReference(ref).String()
+			}
+		}
+	}
+	return tags, nil
+}
+
+// nextLink extracts the target of a rel="next" Link header
+func nextLink(header string) string {
+	for link := range strings.SplitSeq(header, ",") {
+		target, params, _ := strings.Cut(link, ";")
+		if strings.Contains(params, `rel="next"`) {
+			return strings.Trim(strings.TrimSpace(target), "<>")
+		}
+	}
+	return ""
+}
+
+// imageCreated returns the created timestamp of the image config a tag points to. For
+// multi-platform images the linux/amd64 image, or else the first one, is used.
+func (o *OCISource) imageCreated(ctx context.Context, baseURL string, creds *OCIRegistry, repository, reference string) (string, error) {
+	accept := strings.Join(ociManifestTypes, ", ")
+	var manifest ociManifest
+	decode := func(r io.Reader) error {
+		manifest = ociManifest{}
+		return json.NewDecoder(r).Decode(&manifest)
+	}
+
+	if _, err := o.get(ctx, creds, repository, baseURL+"/v2/"+repository+"/manifests/"+reference, accept, decode); err != nil {
+		return "", err
+	}
+	if len(manifest.Manifests) > 0 {
+		digest := manifest.Manifests[0].Digest
+		for _, m := range manifest.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				digest = m.Digest
+				break
+			}
+		}
+		if _, err := o.get(ctx, creds, repository, baseURL+"/v2/"+repository+"/manifests/"+digest, accept, decode); err != nil {
+			return "", err
+		}
+	}
+	if manifest.Config.Digest == "" {
+		return "", fmt.Errorf("manifest of %s has no config", reference)
+	}
+
+	var config struct {
+		Created time.Time `json:"created"`
+	}
+	_, err := o.get(ctx, creds, repository, baseURL+"/v2/"+repository+"/blobs/"+manifest.Config.Digest, "", func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&config)
+	})
+	if err != nil {
+		return "", err
+	}
+	if config.Created.IsZero() {
+		return "", fmt.Errorf("image config of %s has no creation time", reference)
+	}
+
+	return config.Created.UTC().Format(time.RFC3339), nil
+}
+
+// Stats returns the registry requests and retries since the source was created, counting
+// images with at least one retried request as retried packages
+func (o *OCISource) Stats() ClientStats {
+	var retried int64
+	o.retriedImages.Range(func(any, any) bool {
+		retried++
+		return true
+	})
+	return ClientStats{
+		Requests:        o.requests.Load(),
+		Retries:         o.retries.Load(),
+		RetriedPackages: retried,
+	}
+}
+
+// get performs an authenticated GET request, retrying rate-limited and transient failures
+// with the source's retry policy
+func (o *OCISource) get(ctx context.Context, creds *OCIRegistry, repository, rawURL, accept string, decode func(io.Reader) error) (http.Header, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		header, retryAfter, retryable, err := o.getOnce(ctx, creds, repository, rawURL, accept, decode)
+		if err == nil || !retryable || attempt >= o.retry.MaxAttempts {
+			return header, err
+		}
+
+		wait := o.retry.backoff(attempt, retryAfter)
+		if o.retry.MaxElapsed > 0 && time.Since(start)+wait > o.retry.MaxElapsed {
+			return header, fmt.Errorf("%w (gave up after %d attempts)", err, attempt)
+		}
+		o.retries.Add(1)
+		registryURL, _, _ := strings.Cut(rawURL, "/v2/")
+		o.retriedImages.Store(registryURL+"/"+repository, true)
+		o.logger.Debug("Retrying registry request", "url", rawURL, "attempt", attempt+1, "wait", wait, "error", err)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, fmt.Errorf("retry cancelled: %w", err)
+		}
+	}
+}
+
+// getOnce performs a single authenticated GET request and reports whether a failure may be
+// retried. A 401 response with a bearer challenge is answered by requesting a pull token from
+// the announced realm and repeating the request once.
+func (o *OCISource) getOnce(ctx context.Context, creds *OCIRegistry, repository, rawURL, accept string, decode func(io.Reader) error) (http.Header, time.Duration, bool, error) {
+	registryURL, _, _ := strings.Cut(rawURL, "/v2/")
+	tokenKey := registryURL + "/" + repository
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		o.mu.Lock()
+		token := o.tokens[tokenKey]
+		o.mu.Unlock()
+		switch {
+		case token != "":
+			req.Header.Set("Authorization", "Bearer "+token)
+		case creds != nil && creds.Username != "":
+			req.SetBasicAuth(creds.Username, creds.Password)
+		}
+
+		if err := o.limiter.wait(ctx); err != nil {
+			return nil, 0, false, fmt.Errorf("rate limiter: %w", err)
+		}
+		o.requests.Add(1)
+		resp, err := o.httpClient.Do(req)
+		if err != nil {
+			o.logger.Debug("HTTP request failed", "url", rawURL, "error", err)
+			return nil, 0, isRetryableError(ctx, err), fmt.Errorf("HTTP request failed: %w", err)
+		}
+
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 && strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+			_ = resp.Body.Close()
+			token, err := o.fetchToken(ctx, creds, challenge, repository)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			o.mu.Lock()
+			o.tokens[tokenKey] = token
+			o.mu.Unlock()
+			continue
+		}
+
+		defer func() {
+			if closeErr := resp.Body.Close(); closeErr != nil {
+				o.logger.Warn("Failed to close response body", "error", closeErr)
+			}
+		}()
+		if resp.StatusCode != http.StatusOK {
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+			return resp.Header, retryAfter, isRetryableStatus(resp.StatusCode), handleHTTPError(o.logger, resp, rawURL)
+		}
+		if err := decode(resp.Body); err != nil {
+			return resp.Header, 0, false, fmt.Errorf("failed to decode registry response: %w", err)
+		}
+		return resp.Header, 0, false, nil
+	}
+}
+
+// fetchToken requests a pull token for repository as described by a bearer challenge, e.g.
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func (o *OCISource) fetchToken(ctx context.Context, creds *OCIRegistry, challenge, repository string) (string, error) {
+	params := make(map[string]string)
+	for param := range strings.SplitSeq(challenge[len("bearer "):], ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok {
+			params[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("bearer challenge without realm: %s", challenge)
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", "repository:"+repository+":pull")
+
+	header := http.Header{}
+	if creds != nil && creds.Username != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Password)))
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := fetchJSON(ctx, o.httpClient, o.logger, params["realm"]+"?"+query.Encode(), header, &token); err != nil {
+		return "", fmt.Errorf("failed to get registry token: %w", err)
+	}
+
+	return cmp.Or(token.Token, token.AccessToken), nil
+}
@@ -0,0 +1,212 @@
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/package-url/packageurl-go"
+)
+
+func TestImageReference(t *testing.T) {
+	tests := []struct {
+		purl       string
+		registry   string
+		repository string
+		tag        string
+	}{
+		{"pkg:docker/nginx@1.25.3-alpine", "docker.io", "library/nginx", "1.25.3-alpine"},
+		{"pkg:docker/bitnami/redis@7.2.4?repository_url=index.docker.io", "docker.io", "bitnami/redis", "7.2.4"},
+		{"pkg:docker/acme/app@sha256%3Aabc?repository_url=ghcr.io&tag=v1.2.0", "ghcr.io", "acme/app", "v1.2.0"},
+		{"pkg:oci/debian@sha256%3Aabc?repository_url=docker.io/library/debian&tag=12.4", "docker.io", "library/debian", "12.4"},
+		{"pkg:oci/app@sha256%3Aabc?repository_url=https://registry.acme.local/team/app&tag=2.0", "registry.acme.local", "team/app", "2.0"},
+		{"pkg:oci/alpine@sha256%3Aabc?tag=3.19", "docker.io", "library/alpine", "3.19"},
+	}
+
+	for _, tt := range tests {
+		purl, err := packageurl.FromString(tt.purl)
+		if err != nil {
+			t.Fatalf("invalid test PURL %q: %v", tt.purl, err)
+		}
+		registry, repository, tag, err := ImageReference(purl)
+		if err != nil {
+			t.Errorf("%s: no error expected, got: %v", tt.purl, err)
+			continue
+		}
+		if registry != tt.registry || repository != tt.repository || tag != tt.tag {
+			t.Errorf("%s: expected %s/%s:%s, got %s/%s:%s", tt.purl, tt.registry, tt.repository, tt.tag, registry, repository, tag)
+		}
+	}
+}
+
+func TestSplitImageTag(t *testing.T) {
+	for tag, want := range map[string][2]string{
+		"1.25.3":            {"1.25.3", ""},
+		"v2.1":              {"v2.1", ""},
+		"1.25.3-alpine3.19": {"1.25.3", "alpine3.19"},
+		"3.12_slim":         {"3.12", "slim"},
+	} {
+		version, variant, ok := SplitImageTag(tag)
+		if !ok || version != want[0] || variant != want[1] {
+			t.Errorf("%s: expected %v, got %q %q %v", tag, want, version, variant, ok)
+		}
+	}
+	for _, tag := range []string{"latest", "bookworm", "sha-abc123"} {
+		if _, _, ok := SplitImageTag(tag); ok {
+			t.Errorf("%s: expected tag without version", tag)
+		}
+	}
+}
+
+func TestOCISource(t *testing.T) {
+	created := map[string]string{
+		"sha256:c1": "2023-10-24T10:00:00.123456Z",
+		"sha256:c2": "2023-12-19T10:00:00Z",
+		"sha256:c3": "2024-02-14T10:00:00Z",
+	}
+	manifests := map[string]any{
+		"1.25.3-alpine": map[string]any{"config": map[string]string{"digest": "sha256:c1"}},
+		"1.25.4-alpine": map[string]any{"config": map[string]string{"digest": "sha256:c2"}},
+		// Multi-platform images point to the linux/amd64 manifest
+		"1.26.0-alpine": map[string]any{"manifests": []map[string]any{
+			{"digest": "sha256:arm", "platform": map[string]string{"os": "linux", "architecture": "arm64"}},
+			{"digest": "sha256:amd", "platform": map[string]string{"os": "linux", "architecture": "amd64"}},
+		}},
+		"sha256:amd": map[string]any{"config": map[string]string{"digest": "sha256:c3"}},
+	}
+
+	var registry *httptest.Server
+	registry = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:library/nginx:pull" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"pull-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+registry.URL+`/token",service="test-registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/v2/library/nginx/")
+		switch {
+		case path == "tags/list" && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/library/nginx/tags/list?n=1000&last=1.25.4-alpine>; rel="next"`)
+			_, _ = w.Write([]byte(`{"name":"library/nginx","tags":["1.25-alpine","1.25.2-alpine","1.25.3","1.25.3-alpine","1.25.4-alpine"]}`))
+		case path == "tags/list":
+			_, _ = w.Write([]byte(`{"name":"library/nginx","tags":["1.26.0-alpine","latest","alpine"]}`))
+		case path == "manifests/1.25.2-alpine":
+			t.Error("expected no request for tags older than the used one")
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(path, "manifests/"):
+			manifest, ok := manifests[strings.TrimPrefix(path, "manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(manifest)
+		case strings.HasPrefix(path, "blobs/"):
+			_ = json.NewEncoder(w).Encode(map[string]string{"created": created[strings.TrimPrefix(path, "blobs/")]})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+
+	source := NewOCISource(OCIConfig{Registries: []OCIRegistry{{URL: registry.URL}}}, nil, nil)
+	host := strings.TrimPrefix(registry.URL, "http://")
+
+	resp, err := source.GetVersions(context.Background(), "pkg:oci/nginx@sha256%3Aabc?repository_url="+host+"/library/nginx&tag=1.25.3-alpine")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	want := []Version{
+		{Version: "1.25.3", PublishedAt: "2023-10-24T10:00:00Z"},
+		{Version: "1.25.4", PublishedAt: "2023-12-19T10:00:00Z"},
+		{Version: "1.26.0", PublishedAt: "2024-02-14T10:00:00Z"},
+	}
+	if len(resp.Versions) != len(want) {
+		t.Fatalf("expected %d alpine releases, got %+v", len(want), resp.Versions)
+	}
+	for i, v := range resp.Versions {
+		if v.Version != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], v.Version)
+		}
+	}
+
+	if !source.ServesRepository("oci", host+"/library/nginx") || source.ServesRepository("npm", host) {
+		t.Error("expected the source to serve image repositories only")
+	}
+}
+
+func TestOCISourceRetriesAndCapsDatedTags(t *testing.T) {
+	var manifestRequests atomic.Int32
+	var rateLimited atomic.Bool
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v2/redis/")
+		switch {
+		case path == "tags/list":
+			_, _ = w.Write([]byte(`{"tags":["7.0","7.1","7.2","7.3","7.4"]}`))
+		case strings.HasPrefix(path, "manifests/"):
+			if rateLimited.CompareAndSwap(false, true) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			manifestRequests.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]any{"config": map[string]string{"digest": "sha256:" + strings.TrimPrefix(path, "manifests/")}})
+		case strings.HasPrefix(path, "blobs/sha256:"):
+			minor := strings.TrimPrefix(path, "blobs/sha256:7.")
+			_ = json.NewEncoder(w).Encode(map[string]string{"created": "2024-0" + minor + "-01T00:00:00Z"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+
+	source := NewOCISource(OCIConfig{
+		Registries:   []OCIRegistry{{URL: registry.URL}},
+		Retry:        RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		RateLimit:    1000,
+		MaxDatedTags: 3,
+	}, nil, nil)
+	host := strings.TrimPrefix(registry.URL, "http://")
+
+	resp, err := source.GetVersions(context.Background(), "pkg:docker/redis@7.1?repository_url="+host)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	// 7.0 is older than the used tag; the used tag and the two newest tags are dated, while
+	// 7.2 exceeds the cap and is listed without a date
+	dates := make(map[string]string)
+	for _, v := range resp.Versions {
+		dates[v.Version.Version] = v.Version.PublishedAt
+	}
+	if len(dates) != 4 {
+		t.Errorf("expected the used tag and all newer tags, got %+v", resp.Versions)
+	}
+	for _, version := range []string{"7.1", "7.3", "7.4"} {
+		if dates[version] == "" {
+			t.Errorf("expected %s to be dated after the rate-limited request was retried", version)
+		}
+	}
+	if date, ok := dates["7.2"]; !ok || date != "" {
+		t.Errorf("expected 7.2 to be listed without a date, got %q (listed: %v)", date, ok)
+	}
+	if n := manifestRequests.Load(); n != 3 {
+		t.Errorf("expected 3 successful manifest requests, got %d", n)
+	}
+	if stats := source.Stats(); stats.Retries != 1 || stats.RetriedPackages != 1 || stats.Requests != 8 {
+		t.Errorf("expected 8 requests with 1 retry of the image, got %+v", stats)
+	}
+}
@@ -0,0 +1,396 @@
+package deps
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// OSIndex is a local package index of a distro release
+type OSIndex struct {
+	// Type is the PURL type whose packages the index lists: deb, rpm or apk
+	Type string
+	// Release restricts the index to components whose distro qualifier matches, e.g.
+	// debian-12. An empty release serves all components of the type.
+	Release string
+	// Path is a Debian Packages or Sources file, an RPM repository (its directory, repomd.xml
+	// or primary.xml) or an Alpine APKINDEX, either plain or compressed with gzip or bzip2
+	Path string
+}
+
+// osRelease holds the versions of all packages listed by the indexes of one distro release
+type osRelease struct {
+	purlType string
+	release  string
+	packages map[string][]Version
+}
+
+// serves reports whether the release provides versions for components of the given distro
+func (r *osRelease) serves(purlType, distro string) bool {
+	return r.purlType == purlType && (r.release == "" || distro == "" || strings.EqualFold(r.release, distro))
+}
+
+// add records a version of a package, ignoring versions listed by several indexes
+func (r *osRelease) add(name string, version Version) {
+	if slices.ContainsFunc(r.packages[name], func(v Version) bool { return v.Version == version.Version }) {
+		return
+	}
+	r.packages[name] = append(r.packages[name], version)
+}
+
+// OSIndexSource serves the versions of deb, rpm and apk packages from local distro indexes.
+// The lag of an OS package is measured against the newest version its distro release ships,
+// not against upstream releases. Debian indexes carry no publication dates.
+type OSIndexSource struct {
+	releases []*osRelease
+	logger   *slog.Logger
+}
+
+// LoadOSIndexSource reads the given indexes. Indexes of the same type and release are merged,
+// e.g. the main and security repositories of a release.
+func LoadOSIndexSource(indexes []OSIndex, logger *slog.Logger) (*OSIndexSource, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	source := &OSIndexSource{logger: logger}
+	for _, index := range indexes {
+		i := slices.IndexFunc(source.releases, func(r *osRelease) bool {
+			return r.purlType == index.Type && r.release == index.Release
+		})
+		if i < 0 {
+			source.releases = append(source.releases, &osRelease{
+				purlType: index.Type,
+				release:  index.Release,
+				packages: make(map[string][]Version),
+			})
+			i = len(source.releases) - 1
+		}
+		release := source.releases[i]
+
+		var err error
+		switch index.Type {
+		case packageurl.TypeDebian:
+			err = readDebianIndex(index.Path, release)
+		case packageurl.TypeRPM:
+			err = readRPMRepository(index.Path, release)
+		case packageurl.TypeApk:
+			err = readAPKIndex(index.Path, release)
+		default:
+			err = fmt.Errorf("%w: %s", ErrUnsupportedPackageType, index.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s index %q: %w", index.Type, index.Path, err)
+		}
+		logger.Debug("Loaded OS package index", "type", index.Type, "release", index.Release, "path", index.Path, "packages", len(release.packages))
+	}
+
+	return source, nil
+}
+
+// Name identifies the local distro indexes as a version source
+func (s *OSIndexSource) Name() string {
+	return "os-index"
+}
+
+// GetVersions returns the versions of an OS package in all indexes of the component's distro
+// release, given by the PURL's distro qualifier. RPM versions carry their epoch as "epoch:"
+// prefix unless it is zero.
+func (s *OSIndexSource) GetVersions(_ context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeDebian && purl.Type != packageurl.TypeRPM && purl.Type != packageurl.TypeApk {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+	distro := purl.Qualifiers.Map()["distro"]
+
+	var versions []VersionsAPIResponse
+	var served bool
+	for _, release := range s.releases {
+		if !release.serves(purl.Type, distro) {
+			continue
+		}
+		served = true
+		for _, v := range release.packages[purl.Name] {
+			if !slices.ContainsFunc(versions, func(known VersionsAPIResponse) bool { return known.Version.Version == v.Version }) {
+				versions = append(versions, VersionsAPIResponse{Version: v})
+			}
+		}
+	}
+	if !served {
+		return nil, fmt.Errorf("%w: no %s index configured for distro %q", ErrPackageNotFound, purl.Type, distro)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s not listed in %s indexes", ErrPackageNotFound, purl.Name, purl.Type)
+	}
+
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+	s.logger.Debug("Resolved versions from OS package index", "purl", rawPURL, "distro", distro, "count", len(versions))
+
+	metadata := map[string]string{"distro": distro}
+	if purl.Type == packageurl.TypeDebian {
+		metadata[MetadataUndated] = "true"
+	}
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: metadata,
+	}, nil
+}
+
+// openIndexFile opens a local index, transparently decompressing gzip and bzip2 files
+func openIndexFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filepath.Ext(path) {
+	case ".gz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return readCloser{gz, f}, nil
+	case ".bz2":
+		return readCloser{bzip2.NewReader(f), f}, nil
+	case ".xz", ".zst", ".lzma":
+		_ = f.Close()
+		return nil, fmt.Errorf("unsupported compression %s, decompress the index first", filepath.Ext(path))
+	default:
+		return f, nil
+	}
+}
+
+// readCloser reads from a decompressor and closes the underlying file
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// readStanzas calls fn for each blank-line separated stanza of "key: value" lines. Values
+// continued on indented lines are joined with a space, e.g. long Binary lists of Sources files.
+func readStanzas(r io.Reader, fn func(fields map[string]string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	fields := make(map[string]string)
+	var lastKey string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				fn(fields)
+				fields = make(map[string]string)
+			}
+			lastKey = ""
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if lastKey != "" {
+				fields[lastKey] = strings.TrimSpace(fields[lastKey] + " " + strings.TrimSpace(line))
+			}
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[key] = strings.TrimSpace(value)
+			lastKey = key
+		}
+	}
+	if len(fields) > 0 {
+		fn(fields)
+	}
+
+	return scanner.Err()
+}
+
+// readDebianIndex reads package versions from a Debian Packages or Sources file. A Sources
+// stanza names the source package, so its version is also listed for each binary package
+// built from it, e.g. libssl3 for openssl, since components name binary packages.
+func readDebianIndex(path string, release *osRelease) error {
+	f, err := openIndexFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return readStanzas(f, func(fields map[string]string) {
+		name, version := fields["Package"], fields["Version"]
+		if name == "" || version == "" {
+			return
+		}
+		release.add(name, Version{Version: version})
+		for binary := range strings.SplitSeq(fields["Binary"], ",") {
+			if binary = strings.TrimSpace(binary); binary != "" {
+				release.add(binary, Version{Version: version})
+			}
+		}
+	})
+}
+
+// readAPKIndex reads package versions from an APKINDEX file or an APKINDEX.tar.gz archive
+func readAPKIndex(path string, release *osRelease) error {
+	f, err := openIndexFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := io.Reader(f)
+	if strings.HasSuffix(path, ".tar.gz") {
+		archive := tar.NewReader(f)
+		for {
+			header, err := archive.Next()
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("archive contains no APKINDEX")
+			}
+			if err != nil {
+				return err
+			}
+			if header.Name == "APKINDEX" {
+				break
+			}
+		}
+		r = archive
+	}
+
+	return readStanzas(r, func(fields map[string]string) {
+		if fields["P"] == "" || fields["V"] == "" {
+			return
+		}
+		version := Version{Version: fields["V"]}
+		if buildTime, err := strconv.ParseInt(fields["t"], 10, 64); err == nil {
+			version.PublishedAt = time.Unix(buildTime, 0).UTC().Format(time.RFC3339)
+		}
+		release.add(fields["P"], version)
+	})
+}
+
+// rpmRepomd is the subset of repodata/repomd.xml locating the primary metadata
+type rpmRepomd struct {
+	Data []struct {
+		Type     string `xml:"type,attr"`
+		Location struct {
+			Href string `xml:"href,attr"`
+		} `xml:"location"`
+	} `xml:"data"`
+}
+
+// rpmPackage is the subset of a primary.xml package entry used for version histories
+type rpmPackage struct {
+	Name    string `xml:"name"`
+	Version struct {
+		Epoch   string `xml:"epoch,attr"`
+		Version string `xml:"ver,attr"`
+		Release string `xml:"rel,attr"`
+	} `xml:"version"`
+	Time struct {
+		Build int64 `xml:"build,attr"`
+	} `xml:"time"`
+}
+
+// readRPMRepository reads package versions from the primary metadata of an RPM repository.
+// path is the repository directory, its repodata directory, repomd.xml or the primary file.
+func readRPMRepository(path string, release *osRelease) error {
+	primary, err := locateRPMPrimary(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := openIndexFile(primary)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := xml.NewDecoder(f)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %q: %w", primary, err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "package" {
+			continue
+		}
+		var pkg rpmPackage
+		if err := decoder.DecodeElement(&pkg, &start); err != nil {
+			return fmt.Errorf("failed to parse %q: %w", primary, err)
+		}
+		if pkg.Name == "" || pkg.Version.Version == "" {
+			continue
+		}
+
+		version := Version{Version: pkg.Version.Version}
+		if pkg.Version.Release != "" {
+			version.Version += "-" + pkg.Version.Release
+		}
+		if pkg.Version.Epoch != "" && pkg.Version.Epoch != "0" {
+			version.Version = pkg.Version.Epoch + ":" + version.Version
+		}
+		if pkg.Time.Build > 0 {
+			version.PublishedAt = time.Unix(pkg.Time.Build, 0).UTC().Format(time.RFC3339)
+		}
+		release.add(pkg.Name, version)
+	}
+}
+
+// locateRPMPrimary resolves the primary metadata file of an RPM repository via repomd.xml
+func locateRPMPrimary(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	repomd := path
+	if info.IsDir() {
+		repomd = filepath.Join(path, "repodata", "repomd.xml")
+		if _, err := os.Stat(repomd); err != nil {
+			repomd = filepath.Join(path, "repomd.xml")
+		}
+	} else if filepath.Base(path) != "repomd.xml" {
+		return path, nil
+	}
+
+	data, err := os.ReadFile(repomd)
+	if err != nil {
+		return "", err
+	}
+	var metadata rpmRepomd
+	if err := xml.Unmarshal(data, &metadata); err != nil {
+		return "", fmt.Errorf("failed to parse %q: %w", repomd, err)
+	}
+	for _, d := range metadata.Data {
+		if d.Type == "primary" && d.Location.Href != "" {
+			// Locations are relative to the repository root, the parent of repodata
+			root := filepath.Dir(filepath.Dir(repomd))
+			return filepath.Join(root, filepath.FromSlash(d.Location.Href)), nil
+		}
+	}
+
+	return "", fmt.Errorf("%q lists no primary metadata", repomd)
+}
@@ -0,0 +1,229 @@
+package deps
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDebianPackages = `Package: libc6
+Source: glibc
+Version: 2.36-9+deb12u1
+Architecture: amd64
+Description: GNU C Library: Shared libraries
+ Contains the standard libraries that are used by nearly all programs on
+ the system.
+
+Package: zlib1g
+Version: 1:1.2.13.dfsg-1
+Architecture: amd64
+`
+
+const testDebianSecurity = `Package: libc6
+Version: 2.36-9+deb12u4
+Architecture: amd64
+
+Package: libc6
+Version: 2.36-9+deb12u1
+Architecture: arm64
+`
+
+const testDebianSources = `Package: openssl
+Binary: openssl, libssl3, libcrypto3-udeb,
+ libssl-dev, openssl-provider-legacy
+Version: 3.0.11-1~deb12u2
+Maintainer: Debian OpenSSL Team <pkg-openssl-devel@alioth-lists.debian.net>
+`
+
+const testRepomd = `<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo" xmlns:rpm="http://linux.duke.edu/metadata/rpm">
+  <data type="primary">
+    <location href="repodata/0123-primary.xml.gz"/>
+  </data>
+  <data type="filelists">
+    <location href="repodata/4567-filelists.xml.gz"/>
+  </data>
+</repomd>`
+
+const testRPMPrimary = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata xmlns="http://linux.duke.edu/metadata/common" xmlns:rpm="http://linux.duke.edu/metadata/rpm" packages="2">
+<package type="rpm">
+  <name>openssl</name>
+  <arch>x86_64</arch>
+  <version epoch="1" ver="3.0.7" rel="27.el9"/>
+  <time file="1700000000" build="1699000000"/>
+  <format><rpm:license>Apache-2.0</rpm:license></format>
+</package>
+<package type="rpm">
+  <name>bash</name>
+  <arch>x86_64</arch>
+  <version epoch="0" ver="5.1.8" rel="9.el9"/>
+  <time file="1700000000" build="1698000000"/>
+</package>
+</metadata>`
+
+const testAPKIndex = `C:Q1abc=
+P:busybox
+V:1.36.1-r5
+A:x86_64
+t:1700000000
+o:busybox
+
+C:Q1def=
+P:busybox
+V:1.36.1-r15
+A:x86_64
+t:1710000000
+o:busybox
+`
+
+// writeGzip writes data gzip-compressed to path
+func writeGzip(t *testing.T, path string, data []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOSIndexSource(t *testing.T) {
+	dir := t.TempDir()
+
+	writeGzip(t, filepath.Join(dir, "Packages.gz"), []byte(testDebianPackages))
+	if err := os.WriteFile(filepath.Join(dir, "Packages-security"), []byte(testDebianSecurity), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := filepath.Join(dir, "rhel9")
+	if err := os.MkdirAll(filepath.Join(repo, "repodata"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "repodata", "repomd.xml"), []byte(testRepomd), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeGzip(t, filepath.Join(repo, "repodata", "0123-primary.xml.gz"), []byte(testRPMPrimary))
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for name, content := range map[string]string{"DESCRIPTION": "v3.19", "APKINDEX": testAPKIndex} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writeGzip(t, filepath.Join(dir, "APKINDEX.tar.gz"), archive.Bytes())
+
+	source, err := LoadOSIndexSource([]OSIndex{
+		{Type: "deb", Release: "debian-12", Path: filepath.Join(dir, "Packages.gz")},
+		{Type: "deb", Release: "debian-12", Path: filepath.Join(dir, "Packages-security")},
+		{Type: "rpm", Path: repo},
+		{Type: "apk", Release: "alpine-3.19", Path: filepath.Join(dir, "APKINDEX.tar.gz")},
+	}, nil)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	ctx := context.Background()
+	resp, err := source.GetVersions(ctx, "pkg:deb/debian/libc6@2.36-9%2Bdeb12u1?arch=amd64&distro=debian-12")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 || resp.Versions[0].Version.PublishedAt != "" {
+		t.Errorf("expected two undated versions merged from both indexes, got %+v", resp.Versions)
+	}
+	if resp.Metadata[MetadataUndated] != "true" {
+		t.Errorf("expected Debian versions to be marked as undated, got %v", resp.Metadata)
+	}
+
+	resp, err = source.GetVersions(ctx, "pkg:rpm/redhat/openssl@3.0.7-27.el9?epoch=1&distro=rhel-9")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if v := resp.Versions[0].Version; len(resp.Versions) != 1 || v.Version != "1:3.0.7-27.el9" || v.PublishedAt != "2023-11-03T08:26:40Z" {
+		t.Errorf("expected epoch-prefixed version with build time, got %+v", resp.Versions)
+	}
+	if _, ok := resp.Metadata[MetadataUndated]; ok {
+		t.Errorf("expected RPM versions not to be marked as undated, got %v", resp.Metadata)
+	}
+
+	resp, err = source.GetVersions(ctx, "pkg:apk/alpine/busybox@1.36.1-r5?distro=alpine-3.19")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 || resp.Versions[1].Version.Version != "1.36.1-r15" {
+		t.Errorf("expected both busybox versions, got %+v", resp.Versions)
+	}
+
+	if _, err := source.GetVersions(ctx, "pkg:deb/ubuntu/libc6@2.35-0ubuntu3?distro=ubuntu-22.04"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("expected ErrPackageNotFound for an unconfigured release, got: %v", err)
+	}
+	if _, err := source.GetVersions(ctx, "pkg:deb/debian/curl@7.88.1-10?distro=debian-12"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("expected ErrPackageNotFound for an unlisted package, got: %v", err)
+	}
+	if _, err := source.GetVersions(ctx, "pkg:npm/vue@3.5.17"); !errors.Is(err, ErrUnsupportedPackageType) {
+		t.Errorf("expected ErrUnsupportedPackageType, got: %v", err)
+	}
+}
+
+func TestOSIndexSourceDebianSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Sources")
+	if err := os.WriteFile(path, []byte(testDebianSources), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	source, err := LoadOSIndexSource([]OSIndex{{Type: "deb", Release: "debian-12", Path: path}}, nil)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	// Binary packages, including those listed on a continuation line, resolve to their source
+	for _, purl := range []string{
+		"pkg:deb/debian/libssl3@3.0.11-1~deb12u1?arch=amd64&distro=debian-12",
+		"pkg:deb/debian/libssl-dev@3.0.11-1~deb12u1?arch=amd64&distro=debian-12",
+		"pkg:deb/debian/openssl@3.0.11-1~deb12u1?arch=source&distro=debian-12",
+	} {
+		resp, err := source.GetVersions(context.Background(), purl)
+		if err != nil {
+			t.Errorf("%s: no error expected, got: %v", purl, err)
+			continue
+		}
+		if len(resp.Versions) != 1 || resp.Versions[0].Version.Version != "3.0.11-1~deb12u2" {
+			t.Errorf("%s: expected the version of the openssl source package, got %+v", purl, resp.Versions)
+		}
+	}
+}
+
+func TestLoadOSIndexSourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	xz := filepath.Join(dir, "Packages.xz")
+	if err := os.WriteFile(xz, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range []OSIndex{
+		{Type: "deb", Path: filepath.Join(dir, "missing")},
+		{Type: "deb", Path: xz},
+		{Type: "rpm", Path: dir},
+		{Type: "npm", Path: xz},
+	} {
+		if _, err := LoadOSIndexSource([]OSIndex{index}, nil); err == nil {
+			t.Errorf("expected error for %+v", index)
+		}
+	}
+}
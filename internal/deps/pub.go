@@ -0,0 +1,102 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// DefaultPubURL is the public package repository for Dart and Flutter
+const DefaultPubURL = "https://pub.dev"
+
+// pubPackage is the subset of the pub repository API package listing
+type pubPackage struct {
+	Versions []struct {
+		Version   string `json:"version"`
+		Published string `json:"published"`
+		Retracted bool   `json:"retracted"`
+	} `json:"versions"`
+}
+
+// PubSource reads version histories from pub.dev or a repository implementing the hosted
+// pub repository specification v2
+type PubSource struct {
+	repositoryURL string
+	httpClient    *http.Client
+	logger        *slog.Logger
+}
+
+// NewPubSource creates a source for the pub repository at repositoryURL, defaulting to
+// pub.dev. A nil httpClient uses default settings.
+func NewPubSource(repositoryURL string, httpClient *http.Client, logger *slog.Logger) *PubSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if repositoryURL == "" {
+		repositoryURL = DefaultPubURL
+	}
+
+	return &PubSource{
+		repositoryURL: strings.TrimSuffix(repositoryURL, "/"),
+		httpClient:    defaultHTTPClient(httpClient),
+		logger:        logger,
+	}
+}
+
+// Name identifies pub as a version source
+func (p *PubSource) Name() string {
+	return "pub"
+}
+
+// ServesRepository reports whether repositoryURL is the configured repository
+func (p *PubSource) ServesRepository(purlType, repositoryURL string) bool {
+	return purlType == packageurl.TypePub && sameRepository(p.repositoryURL, repositoryURL)
+}
+
+// GetVersions returns all versions of a Dart package, marking versions retracted by their
+// publisher.
+func (p *PubSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypePub {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	packageURL := p.repositoryURL + "/api/packages/" + url.PathEscape(strings.ToLower(purl.Name))
+	p.logger.Debug("Fetching pub package", "purl", rawPURL, "url", packageURL)
+
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.pub.v2+json")
+
+	var pkg pubPackage
+	if err := fetchJSON(ctx, p.httpClient, p.logger, packageURL, header, &pkg); err != nil {
+		return nil, err
+	}
+
+	versions := make([]VersionsAPIResponse, 0, len(pkg.Versions))
+	for _, v := range pkg.Versions {
+		versions = append(versions, VersionsAPIResponse{Version: Version{
+			Version:     v.Version,
+			PublishedAt: v.Published,
+			Retracted:   v.Retracted,
+		}})
+	}
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	p.logger.Debug("Successfully retrieved pub versions", "url", packageURL, "count", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"repository": p.repositoryURL},
+	}, nil
+}
@@ -0,0 +1,34 @@
+package deps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPubSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/packages/http" || r.Header.Get("Accept") != "application/vnd.pub.v2+json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "http", "versions": [
+			{"version": "1.1.0", "published": "2023-07-01T00:00:00.000Z"},
+			{"version": "1.1.1", "published": "2023-08-01T00:00:00.000Z", "retracted": true},
+			{"version": "1.2.0", "published": "2024-01-01T00:00:00.000Z"}
+		]}`))
+	}))
+	defer server.Close()
+
+	resp, err := NewPubSource(server.URL, nil, nil).GetVersions(context.Background(), "pkg:pub/http@1.1.0")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(resp.Versions))
+	}
+	if retracted := resp.Versions[1].Version; retracted.Version != "1.1.1" || !retracted.Retracted {
+		t.Errorf("expected 1.1.1 to be retracted, got %+v", retracted)
+	}
+}
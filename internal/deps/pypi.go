@@ -0,0 +1,113 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// DefaultPyPIIndex is the public Python package index
+const DefaultPyPIIndex = "https://pypi.org"
+
+// pypiNameSeparators matches the runs of separators that PEP 503 normalizes to a dash
+var pypiNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// pypiFile is a single distribution file of a release in the PyPI JSON API
+type pypiFile struct {
+	UploadTime string `json:"upload_time_iso_8601"`
+	Yanked     bool   `json:"yanked"`
+}
+
+// pypiProject is the subset of the PyPI JSON API project response used for version histories
+type pypiProject struct {
+	Releases map[string][]pypiFile `json:"releases"`
+}
+
+// PyPISource reads version histories from the PyPI JSON API of pypi.org or a compatible mirror
+type PyPISource struct {
+	indexURL   string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewPyPISource creates a PyPI source for the given index URL, defaulting to pypi.org.
+// A nil httpClient uses default settings.
+func NewPyPISource(indexURL string, httpClient *http.Client, logger *slog.Logger) *PyPISource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if indexURL == "" {
+		indexURL = DefaultPyPIIndex
+	}
+
+	return &PyPISource{
+		indexURL:   strings.TrimSuffix(indexURL, "/"),
+		httpClient: defaultHTTPClient(httpClient),
+		logger:     logger,
+	}
+}
+
+// Name identifies PyPI as a version source
+func (p *PyPISource) Name() string {
+	return "pypi"
+}
+
+// ServesRepository reports whether repositoryURL is the configured index
+func (p *PyPISource) ServesRepository(purlType, repositoryURL string) bool {
+	return purlType == packageurl.TypePyPi && sameRepository(p.indexURL, repositoryURL)
+}
+
+// GetVersions returns all releases of a Python project. A release is published with its
+// first uploaded file and counts as yanked only if all of its files are yanked (PEP 592).
+func (p *PyPISource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypePyPi {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	name := pypiNameSeparators.ReplaceAllString(strings.ToLower(purl.Name), "-")
+	projectURL := fmt.Sprintf("%s/pypi/%s/json", p.indexURL, url.PathEscape(name))
+	p.logger.Debug("Fetching PyPI project", "purl", rawPURL, "url", projectURL)
+
+	var project pypiProject
+	if err := fetchJSON(ctx, p.httpClient, p.logger, projectURL, nil, &project); err != nil {
+		return nil, err
+	}
+
+	versions := make([]VersionsAPIResponse, 0, len(project.Releases))
+	for release, files := range project.Releases {
+		if len(files) == 0 {
+			p.logger.Debug("Skipping release without files", "project", name, "version", release)
+			continue
+		}
+
+		version := Version{Version: release, Yanked: true}
+		for _, file := range files {
+			if version.PublishedAt == "" || file.UploadTime < version.PublishedAt {
+				version.PublishedAt = file.UploadTime
+			}
+			version.Yanked = version.Yanked && file.Yanked
+		}
+		versions = append(versions, VersionsAPIResponse{Version: version})
+	}
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	p.logger.Debug("Successfully retrieved PyPI versions", "url", projectURL, "count", len(versions))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"index": p.indexURL},
+	}, nil
+}
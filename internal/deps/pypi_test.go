@@ -0,0 +1,59 @@
+package deps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testPyPIProject = `{
+  "info": {"name": "Acme_Utils"},
+  "releases": {
+    "1.0": [
+      {"upload_time_iso_8601": "2022-01-02T00:00:00.000000Z", "yanked": false},
+      {"upload_time_iso_8601": "2022-01-01T00:00:00.000000Z", "yanked": false}
+    ],
+    "1.1": [{"upload_time_iso_8601": "2022-02-01T00:00:00.000000Z", "yanked": true}],
+    "1.2": [
+      {"upload_time_iso_8601": "2022-03-01T00:00:00.000000Z", "yanked": true},
+      {"upload_time_iso_8601": "2022-03-01T00:00:00.000000Z", "yanked": false}
+    ],
+    "2.0": []
+  }
+}`
+
+func TestPyPISource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/root/pypi/pypi/acme-utils/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(testPyPIProject))
+	}))
+	defer server.Close()
+
+	source := NewPyPISource(server.URL+"/root/pypi/", nil, nil)
+	resp, err := source.GetVersions(context.Background(), "pkg:pypi/Acme.Utils@1.0")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	byVersion := make(map[string]Version)
+	for _, v := range resp.Versions {
+		byVersion[v.Version.Version] = v.Version
+	}
+
+	if len(byVersion) != 3 {
+		t.Fatalf("expected 3 releases with files, got %d", len(byVersion))
+	}
+	if byVersion["1.0"].PublishedAt != "2022-01-01T00:00:00.000000Z" {
+		t.Errorf("expected earliest upload time as publication date, got %q", byVersion["1.0"].PublishedAt)
+	}
+	if !byVersion["1.1"].Yanked {
+		t.Errorf("expected 1.1 to be yanked")
+	}
+	if byVersion["1.2"].Yanked {
+		t.Errorf("expected 1.2 not to be yanked since one file is still available")
+	}
+}
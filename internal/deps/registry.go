@@ -0,0 +1,117 @@
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// defaultHTTPClient returns client, or a client with the default request timeout if nil
+func defaultHTTPClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: requestTimeout}
+}
+
+// fetch performs a GET request against a registry and passes the body of a successful
+// response to decode. Non-200 responses are mapped by handleHTTPError.
+func fetch(ctx context.Context, httpClient *http.Client, logger *slog.Logger, url string, header http.Header, decode func(io.Reader) error) error {
+	return send(ctx, httpClient, logger, http.MethodGet, url, header, nil, decode)
+}
+
+// send performs a request with an optional body and passes the body of a successful
+// response to decode. Non-200 responses are mapped by handleHTTPError.
+func send(ctx context.Context, httpClient *http.Client, logger *slog.Logger, method, url string, header http.Header, body io.Reader, decode func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		logger.Debug("HTTP request failed", "url", url, "error", err)
+		return fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Warn("Failed to close response body", "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return handleHTTPError(logger, resp, url)
+	}
+
+	if err := decode(resp.Body); err != nil {
+		logger.Debug("Failed to decode response", "url", url, "error", err)
+		return fmt.Errorf("failed to decode registry response: %w", err)
+	}
+
+	return nil
+}
+
+// fetchHeader performs a HEAD request and returns the response headers of a successful response
+func fetchHeader(ctx context.Context, httpClient *http.Client, logger *slog.Logger, url string, header http.Header) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		logger.Debug("HTTP request failed", "url", url, "error", err)
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	if closeErr := resp.Body.Close(); closeErr != nil {
+		logger.Warn("Failed to close response body", "error", closeErr)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleHTTPError(logger, resp, url)
+	}
+
+	return resp.Header, nil
+}
+
+// forEachConcurrently calls fn for every index below count using at most workers goroutines
+func forEachConcurrently(workers, count int, fn func(i int)) {
+	indices := make(chan int)
+	var wg sync.WaitGroup
+
+	for range min(workers, count) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+	for i := range count {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+// fetchJSON performs a GET request and decodes the JSON response body into v
+func fetchJSON(ctx context.Context, httpClient *http.Client, logger *slog.Logger, url string, header http.Header, v any) error {
+	return fetch(ctx, httpClient, logger, url, header, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(v)
+	})
+}
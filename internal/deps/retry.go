@@ -0,0 +1,110 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy bounds how often and how long failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first request; values <= 1 disable retries
+	MaxAttempts int
+	// MaxElapsed is the time budget for all attempts of one request; 0 means no budget
+	MaxElapsed time.Duration
+	// BaseDelay is the backoff before the first retry, doubled for every further retry
+	BaseDelay time.Duration
+	// MaxDelay caps a single backoff
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy retries rate-limited and transient failures a few times within two minutes
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MaxElapsed:  2 * time.Minute,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// backoff returns the wait before the given retry (1-based) using exponential backoff with
+// full jitter. A server-provided Retry-After takes precedence.
+func (p RetryPolicy) backoff(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	delay := p.MaxDelay
+	if shift := retry - 1; shift < 32 {
+		if d := p.BaseDelay << shift; d > 0 && d < p.MaxDelay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	return rand.N(delay) + 1
+}
+
+// isRetryableStatus reports whether an HTTP status indicates a rate limit or transient server failure
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isRetryableError reports whether a transport error is a timeout or a dropped connection.
+// Cancellation of the caller's context is never retried.
+func isRetryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, net.ErrClosed) ||
+		strings.Contains(err.Error(), "connection reset")
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+
+	return 0
+}
+
+// sleepContext waits for the given duration or until the context is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
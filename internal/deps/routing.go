@@ -0,0 +1,126 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// ErrUnknownRepository is returned for PURLs whose repository_url qualifier names a
+// repository that no configured backend serves
+var ErrUnknownRepository = errors.New("unknown repository")
+
+// repositoryQualifier is the PURL qualifier naming the repository a package comes from
+const repositoryQualifier = "repository_url"
+
+// defaultRepositoryHosts are the public repositories of each PURL type, which deps.dev mirrors
+var defaultRepositoryHosts = map[string][]string{
+	packageurl.TypeCargo:  {"crates.io", "index.crates.io", "static.crates.io"},
+	packageurl.TypeGem:    {"rubygems.org"},
+	packageurl.TypeGolang: {"proxy.golang.org"},
+	packageurl.TypeGradle: {"repo.maven.apache.org", "repo1.maven.org"},
+	packageurl.TypeMaven:  {"repo.maven.apache.org", "repo1.maven.org"},
+	packageurl.TypeNPM:    {"registry.npmjs.org", "registry.npmjs.com", "registry.yarnpkg.com"},
+	packageurl.TypeNuget:  {"www.nuget.org", "nuget.org", "api.nuget.org"},
+	packageurl.TypePyPi:   {"pypi.org", "pypi.python.org", "files.pythonhosted.org"},
+}
+
+// RepositorySource is a VersionSource bound to specific repositories, e.g. a private registry
+type RepositorySource interface {
+	VersionSource
+	// ServesRepository reports whether packages of the PURL type from repositoryURL are served by the source
+	ServesRepository(purlType, repositoryURL string) bool
+}
+
+// RepositoryRouter resolves PURLs that declare their repository with a repository_url
+// qualifier against the backend serving that repository. PURLs without the qualifier or
+// pointing to a public default repository go to the fallback source.
+type RepositoryRouter struct {
+	fallback VersionSource
+	sources  []VersionSource
+	logger   *slog.Logger
+}
+
+// NewRepositoryRouter creates a router over the given backends. Sources that do not
+// implement RepositorySource are never routed to.
+func NewRepositoryRouter(logger *slog.Logger, fallback VersionSource, sources ...VersionSource) *RepositoryRouter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &RepositoryRouter{
+		fallback: fallback,
+		sources:  sources,
+		logger:   logger,
+	}
+}
+
+// Name returns the name of the fallback source, which resolves most packages
+func (r *RepositoryRouter) Name() string {
+	return r.fallback.Name()
+}
+
+// GetVersions asks the backend serving the repository declared in the PURL, or the fallback
+// source if no repository or a default repository is declared. A declared repository that
+// no backend serves fails with ErrUnknownRepository instead of being looked up elsewhere.
+func (r *RepositoryRouter) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return r.fallback.GetVersions(ctx, rawPURL)
+	}
+
+	declared := purlRepositoryURL(purl)
+	if declared == "" || isDefaultRepository(purl.Type, declared) {
+		return r.fallback.GetVersions(ctx, rawPURL)
+	}
+
+	for _, source := range r.sources {
+		if repoSource, ok := source.(RepositorySource); ok && repoSource.ServesRepository(purl.Type, declared) {
+			r.logger.Debug("Routing package to its declared repository", "purl", rawPURL, "repository", declared, "source", source.Name())
+			resp, err := source.GetVersions(ctx, rawPURL)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", source.Name(), err)
+			}
+			return resp, nil
+		}
+	}
+
+	r.logger.Debug("No backend serves declared repository", "purl", rawPURL, "repository", declared)
+	return nil, fmt.Errorf("%w: %s", ErrUnknownRepository, declared)
+}
+
+// purlRepositoryURL returns the repository_url qualifier of a PURL
+func purlRepositoryURL(purl packageurl.PackageURL) string {
+	return purl.Qualifiers.Map()[repositoryQualifier]
+}
+
+// isDefaultRepository reports whether repositoryURL is a public default repository of the PURL type
+func isDefaultRepository(purlType, repositoryURL string) bool {
+	host, _, _ := strings.Cut(normalizeRepositoryURL(repositoryURL), "/")
+	return slices.Contains(defaultRepositoryHosts[purlType], host)
+}
+
+// sameRepository reports whether a declared repository URL refers to the configured one or
+// a location below it. Schemes are ignored since PURLs often omit them.
+func sameRepository(configured, declared string) bool {
+	configured = normalizeRepositoryURL(configured)
+	declared = normalizeRepositoryURL(declared)
+	return configured != "" && (declared == configured || strings.HasPrefix(declared, configured+"/"))
+}
+
+// normalizeRepositoryURL strips the scheme, a cargo "sparse+" prefix and trailing slashes and
+// lowercases the host
+func normalizeRepositoryURL(repositoryURL string) string {
+	repositoryURL = strings.TrimPrefix(strings.TrimSpace(repositoryURL), "sparse+")
+	if u, err := url.Parse(repositoryURL); err == nil && u.Host != "" {
+		repositoryURL = u.Host + u.Path
+	}
+	host, path, _ := strings.Cut(repositoryURL, "/")
+	return strings.TrimSuffix(strings.ToLower(host)+"/"+path, "/")
+}
@@ -0,0 +1,108 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRepositoryRouter(t *testing.T) {
+	var nexusCalls, otherCalls atomic.Int32
+	nexus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nexusCalls.Add(1)
+		if r.URL.Path == "/repository/releases/com/acme/lib/maven-metadata.xml" {
+			_, _ = w.Write([]byte(testMavenMetadata))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer nexus.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherCalls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer other.Close()
+
+	maven := NewMavenSource([]MavenRepository{{URL: other.URL}, {URL: nexus.URL + "/repository/releases/"}}, nil, nil)
+	fallback := &staticSource{name: "deps.dev", packages: map[string]*APIResponse{"maven/com.acme%2Flib": testResponse()}}
+	router := NewRepositoryRouter(nil, fallback, maven)
+
+	// The declared repository is asked directly, skipping the other configured one
+	declared := "pkg:maven/com.acme/lib@1.0.0?repository_url=" + url.QueryEscape(nexus.URL+"/repository/releases")
+	if _, err := router.GetVersions(context.Background(), declared); err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if otherCalls.Load() != 0 || fallback.calls != 0 {
+		t.Errorf("expected only the declared repository to be asked, other=%d fallback=%d", otherCalls.Load(), fallback.calls)
+	}
+
+	// PURLs without a repository or with the default repository use the fallback
+	for _, purl := range []string{
+		"pkg:maven/com.acme/lib@1.0.0",
+		"pkg:maven/com.acme/lib@1.0.0?repository_url=https://repo.maven.apache.org/maven2",
+	} {
+		if _, err := router.GetVersions(context.Background(), purl); err != nil {
+			t.Errorf("%s: no error expected, got: %v", purl, err)
+		}
+	}
+	if fallback.calls != 2 {
+		t.Errorf("expected 2 fallback calls, got %d", fallback.calls)
+	}
+
+	_, err := router.GetVersions(context.Background(), "pkg:maven/com.acme/lib@1.0.0?repository_url=https://nexus.unknown.local/releases")
+	if !errors.Is(err, ErrUnknownRepository) {
+		t.Errorf("expected ErrUnknownRepository, got: %v", err)
+	}
+	if fallback.calls != 2 {
+		t.Errorf("expected unknown repository not to reach the fallback")
+	}
+}
+
+func TestNpmSourceUsesDeclaredRegistry(t *testing.T) {
+	var gotPath string
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"versions":{"1.0.0":{}},"time":{"1.0.0":"2024-01-01T00:00:00Z"}}`))
+	}))
+	defer registry.Close()
+
+	source := NewNpmSource(NpmConfig{
+		Registry:        "https://registry.npmjs.org",
+		ScopeRegistries: map[string]string{"@acme": registry.URL + "/npm/"},
+	}, nil, nil)
+	if !source.ServesRepository("npm", registry.URL+"/npm") || source.ServesRepository("npm", "https://npm.unknown.local") {
+		t.Fatal("unexpected ServesRepository result")
+	}
+
+	// An unscoped package declaring the scoped registry is read from there
+	purl := "pkg:npm/left-pad@1.0.0?repository_url=" + url.QueryEscape(registry.URL+"/npm")
+	if _, err := source.GetVersions(context.Background(), purl); err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if gotPath != "/npm/left-pad" {
+		t.Errorf("unexpected packument path %q", gotPath)
+	}
+}
+
+func TestSameRepository(t *testing.T) {
+	tests := []struct {
+		configured, declared string
+		want                 bool
+	}{
+		{"https://nexus.acme.local/repository/releases/", "https://NEXUS.acme.local/repository/releases", true},
+		{"https://nexus.acme.local/repository/releases", "nexus.acme.local/repository/releases", true},
+		{"https://devpi.acme.local/root/pypi", "https://devpi.acme.local/root/pypi/simple", true},
+		{"sparse+https://cargo.acme.local/index/", "https://cargo.acme.local/index", true},
+		{"https://nexus.acme.local/repository/releases", "https://nexus.acme.local/repository/releases-old", false},
+		{"https://nexus.acme.local/repository/releases", "https://nexus.acme.local/repository", false},
+	}
+	for _, tt := range tests {
+		if got := sameRepository(tt.configured, tt.declared); got != tt.want {
+			t.Errorf("sameRepository(%q, %q) = %v, want %v", tt.configured, tt.declared, got, tt.want)
+		}
+	}
+}
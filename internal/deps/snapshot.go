@@ -0,0 +1,162 @@
+package deps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// ErrNotInSnapshot is returned when an offline snapshot has no entry for a package
+var ErrNotInSnapshot = errors.New("package not found in offline versions snapshot")
+
+// Snapshot is a self-contained versions database used to calculate technical lag offline.
+// Entries are keyed by PURL type and package name, see PackageKey.
+type Snapshot struct {
+	GeneratedAt time.Time               `json:"generatedAt"`
+	Packages    map[string]*APIResponse `json:"packages"`
+
+	mu sync.RWMutex
+}
+
+// NewSnapshot creates an empty snapshot
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		GeneratedAt: time.Now().UTC(),
+		Packages:    make(map[string]*APIResponse),
+	}
+}
+
+// LoadSnapshot reads a snapshot from a JSON file
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions snapshot: %w", err)
+	}
+
+	snapshot := NewSnapshot()
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode versions snapshot %q: %w", path, err)
+	}
+	if snapshot.Packages == nil {
+		snapshot.Packages = make(map[string]*APIResponse)
+	}
+
+	return snapshot, nil
+}
+
+// Save writes the snapshot to a JSON file
+func (s *Snapshot) Save(path string) error {
+	s.mu.RLock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode versions snapshot: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write versions snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Add records the versions of the package identified by rawPURL
+func (s *Snapshot) Add(rawPURL string, resp *APIResponse) error {
+	key, err := PackageKey(rawPURL)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Packages[key] = resp
+
+	return nil
+}
+
+// Contains reports whether the snapshot holds the package identified by rawPURL
+func (s *Snapshot) Contains(rawPURL string) bool {
+	key, err := PackageKey(rawPURL)
+	if err != nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.Packages[key]
+
+	return ok
+}
+
+// Len returns the number of packages in the snapshot
+func (s *Snapshot) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.Packages)
+}
+
+// Name identifies the snapshot as a version source
+func (s *Snapshot) Name() string {
+	return "snapshot"
+}
+
+// GetVersions returns the recorded versions for a package without any network access
+func (s *Snapshot) GetVersions(_ context.Context, rawPURL string) (*APIResponse, error) {
+	key, err := PackageKey(rawPURL)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp, ok := s.Packages[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotInSnapshot, key)
+	}
+
+	return resp, nil
+}
+
+// PackageKey returns the key of a package in snapshots, its PURL type and escaped namespace
+// and name, e.g. npm/@vue%2Fshared. Unlike deps.dev lookups it covers every PURL type, so
+// that packages of all sources can be exported and resolved offline. Qualifiers that select
+// other versions of the same name are kept: the distro of OS packages, e.g.
+// deb/openssl?distro=debian-12, and a repository_url other than the public default, which
+// RepositoryRouter resolves against a private repository.
+func PackageKey(rawPURL string) (string, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+
+	name := purl.Name
+	if purl.Namespace != "" {
+		name = purl.Namespace + "/" + name
+	}
+	if purl.Type == "" || name == "" {
+		return "", fmt.Errorf("%w %q: missing type or name", ErrInvalidPURL, rawPURL)
+	}
+
+	qualifiers := url.Values{}
+	if distro := purl.Qualifiers.Map()["distro"]; distro != "" {
+		qualifiers.Set("distro", distro)
+	}
+	if declared := purlRepositoryURL(purl); declared != "" && !isDefaultRepository(purl.Type, declared) {
+		qualifiers.Set(repositoryQualifier, normalizeRepositoryURL(declared))
+	}
+
+	key := cacheKey(strings.ToLower(purl.Type), url.PathEscape(name))
+	if len(qualifiers) > 0 {
+		key += "?" + qualifiers.Encode()
+	}
+	return key, nil
+}
@@ -0,0 +1,101 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	snapshot := NewSnapshot()
+	if err := snapshot.Add("pkg:npm/%40vue/shared@3.5.17", testResponse()); err != nil {
+		t.Fatalf("failed to add package: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := snapshot.Save(path); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+
+	// Another version of the same package must resolve to the same entry
+	resp, err := loaded.GetVersions(context.Background(), "pkg:npm/%40vue/shared@3.4.0")
+	if err != nil {
+		t.Fatalf("expected package in snapshot, got: %v", err)
+	}
+	if len(resp.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(resp.Versions))
+	}
+}
+
+func TestSnapshotKeyedByDistro(t *testing.T) {
+	bookworm := &APIResponse{Versions: []VersionsAPIResponse{{Version: Version{Version: "3.0.11-1~deb12u2"}}}}
+	bullseye := &APIResponse{Versions: []VersionsAPIResponse{{Version: Version{Version: "1.1.1w-0+deb11u1"}}}}
+
+	snapshot := NewSnapshot()
+	if err := snapshot.Add("pkg:deb/debian/openssl@3.0.11-1~deb12u2?distro=debian-12", bookworm); err != nil {
+		t.Fatalf("failed to add package: %v", err)
+	}
+	if err := snapshot.Add("pkg:deb/debian/openssl@1.1.1w-0+deb11u1?arch=amd64&distro=debian-11", bullseye); err != nil {
+		t.Fatalf("failed to add package: %v", err)
+	}
+	if snapshot.Len() != 2 {
+		t.Fatalf("expected an entry per distro release, got %d", snapshot.Len())
+	}
+
+	resp, err := snapshot.GetVersions(context.Background(), "pkg:deb/debian/openssl@3.0.13-1~deb12u1?arch=arm64&distro=debian-12")
+	if err != nil {
+		t.Fatalf("expected package in snapshot, got: %v", err)
+	}
+	if resp != bookworm {
+		t.Errorf("expected the versions of debian-12, got %+v", resp)
+	}
+	if _, err := snapshot.GetVersions(context.Background(), "pkg:deb/debian/openssl@3.0.11-1~deb12u2"); !errors.Is(err, ErrNotInSnapshot) {
+		t.Errorf("expected ErrNotInSnapshot without distro, got: %v", err)
+	}
+}
+
+func TestSnapshotKeyedByRepository(t *testing.T) {
+	private := &APIResponse{Versions: []VersionsAPIResponse{{Version: Version{Version: "1.0.0-acme.3"}}}}
+	public := &APIResponse{Versions: []VersionsAPIResponse{{Version: Version{Version: "1.2.0"}}}}
+
+	snapshot := NewSnapshot()
+	if err := snapshot.Add("pkg:maven/com.acme/lib@1.0.0?repository_url=https://nexus.acme.local/repository/releases/", private); err != nil {
+		t.Fatalf("failed to add package: %v", err)
+	}
+	if err := snapshot.Add("pkg:maven/com.acme/lib@1.0.0", public); err != nil {
+		t.Fatalf("failed to add package: %v", err)
+	}
+
+	tests := map[string]*APIResponse{
+		// Scheme and trailing slash do not matter, like for routing
+		"pkg:maven/com.acme/lib@1.1.0?repository_url=nexus.acme.local/repository/releases": private,
+		// The public default repository shares the entry of PURLs without the qualifier
+		"pkg:maven/com.acme/lib@1.1.0?repository_url=https://repo.maven.apache.org/maven2": public,
+		"pkg:maven/com.acme/lib@1.1.0": public,
+	}
+	for purl, want := range tests {
+		resp, err := snapshot.GetVersions(context.Background(), purl)
+		if err != nil {
+			t.Errorf("%s: expected package in snapshot, got: %v", purl, err)
+			continue
+		}
+		if resp != want {
+			t.Errorf("%s: expected versions %+v, got %+v", purl, want.Versions, resp.Versions)
+		}
+	}
+}
+
+func TestSnapshotMissingPackage(t *testing.T) {
+	snapshot := NewSnapshot()
+
+	_, err := snapshot.GetVersions(context.Background(), "pkg:npm/vue@3.5.17")
+	if !errors.Is(err, ErrNotInSnapshot) {
+		t.Fatalf("expected ErrNotInSnapshot, got: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+var (
+	// ErrPackageNotFound is returned when a source does not know the requested package
+	ErrPackageNotFound = errors.New("package not found")
+	// ErrUnsupportedPackageType is returned when a source cannot handle the PURL type
+	ErrUnsupportedPackageType = errors.New("unsupported package type")
+	// ErrInvalidPURL is returned for package URLs that cannot be parsed or lack required parts
+	ErrInvalidPURL = errors.New("invalid PURL")
+	// ErrRateLimited is returned when a server keeps rejecting requests with HTTP 429
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// VersionSource provides the release history of a package identified by its PURL.
+// Implementations return ErrUnsupportedPackageType for PURL types they do not handle and
+// ErrPackageNotFound for unknown packages, so that a ChainSource can try the next source.
+type VersionSource interface {
+	// Name identifies the source in logs
+	Name() string
+	// GetVersions returns all known versions of the package with their publication dates
+	GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error)
+}
+
+// ChainSource queries several sources in order and returns the first successful answer
+type ChainSource struct {
+	sources []VersionSource
+	logger  *slog.Logger
+}
+
+// NewChainSource creates a source that tries each of the given sources in order
+func NewChainSource(logger *slog.Logger, sources ...VersionSource) *ChainSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &ChainSource{
+		sources: sources,
+		logger:  logger,
+	}
+}
+
+// Name returns the names of all chained sources
+func (c *ChainSource) Name() string {
+	names := make([]string, 0, len(c.sources))
+	for _, source := range c.sources {
+		names = append(names, source.Name())
+	}
+	return "chain(" + strings.Join(names, ",") + ")"
+}
+
+// GetVersions asks each source in turn. A source that does not support the package type or
+// does not know the package passes on to the next one; any other error is returned directly.
+func (c *ChainSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	if len(c.sources) == 0 {
+		return nil, fmt.Errorf("no version sources configured")
+	}
+
+	var errs []error
+	for _, source := range c.sources {
+		resp, err := source.GetVersions(ctx, rawPURL)
+		if err == nil {
+			c.logger.Debug("Resolved versions", "purl", rawPURL, "source", source.Name())
+			return resp, nil
+		}
+
+		if !errors.Is(err, ErrUnsupportedPackageType) && !errors.Is(err, ErrPackageNotFound) {
+			return nil, fmt.Errorf("%s: %w", source.Name(), err)
+		}
+
+		c.logger.Debug("Source could not resolve package, trying next", "purl", rawPURL, "source", source.Name(), "error", err)
+		errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+	}
+
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,122 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// staticSource is a VersionSource answering from a fixed map of package keys
+type staticSource struct {
+	name     string
+	packages map[string]*APIResponse
+	err      error
+	calls    int
+}
+
+func (s *staticSource) Name() string { return s.name }
+
+func (s *staticSource) GetVersions(_ context.Context, rawPURL string) (*APIResponse, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	key, err := PackageKey(rawPURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp, ok := s.packages[key]; ok {
+		return resp, nil
+	}
+	return nil, ErrPackageNotFound
+}
+
+func TestChainSourceFallsThrough(t *testing.T) {
+	private := &staticSource{name: "private", packages: map[string]*APIResponse{"npm/@acme%2Finternal": testResponse()}}
+	public := &staticSource{name: "public", packages: map[string]*APIResponse{"npm/vue": testResponse()}}
+	chain := NewChainSource(nil, private, public)
+
+	if _, err := chain.GetVersions(context.Background(), "pkg:npm/%40acme/internal@1.0.0"); err != nil {
+		t.Fatalf("expected private package to resolve, got: %v", err)
+	}
+	if public.calls != 0 {
+		t.Fatalf("expected public source not to be asked for a private package")
+	}
+
+	if _, err := chain.GetVersions(context.Background(), "pkg:npm/vue@3.5.17"); err != nil {
+		t.Fatalf("expected public package to resolve, got: %v", err)
+	}
+	if private.calls != 2 || public.calls != 1 {
+		t.Fatalf("unexpected calls: private=%d public=%d", private.calls, public.calls)
+	}
+
+	_, err := chain.GetVersions(context.Background(), "pkg:npm/unknown@1.0.0")
+	if !errors.Is(err, ErrPackageNotFound) {
+		t.Fatalf("expected ErrPackageNotFound, got: %v", err)
+	}
+}
+
+func TestChainSourceStopsOnOtherErrors(t *testing.T) {
+	broken := &staticSource{name: "broken", err: errors.New("connection refused")}
+	public := &staticSource{name: "public", packages: map[string]*APIResponse{"npm/vue": testResponse()}}
+	chain := NewChainSource(nil, broken, public)
+
+	if _, err := chain.GetVersions(context.Background(), "pkg:npm/vue@3.5.17"); err == nil {
+		t.Fatalf("expected error of the first source to be returned")
+	}
+	if public.calls != 0 {
+		t.Fatalf("expected chain to stop at the failing source")
+	}
+}
+
+func TestRegistrySourceFailures(t *testing.T) {
+	// Each registry answers the package "missing" with 404, "broken" with 500 and "garbled"
+	// with a body that is not a valid response
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.Contains(r.URL.Path, "broken"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{"versions": [`))
+		}
+	}))
+	defer server.Close()
+
+	sources := []struct {
+		source  VersionSource
+		purlFmt string
+	}{
+		{NewComposerSource(server.URL, nil, nil), "pkg:composer/acme/%s@1.0.0"},
+		{NewHexSource(server.URL, nil, nil), "pkg:hex/%s@1.0.0"},
+		{NewPubSource(server.URL, nil, nil), "pkg:pub/%s@1.0.0"},
+		{NewCocoaPodsSource(server.URL, nil, nil), "pkg:cocoapods/%s@1.0.0"},
+		{NewSwiftSource(server.URL, nil, nil), "pkg:swift/github.com/acme/%s@1.0.0"},
+	}
+
+	for _, tt := range sources {
+		t.Run(tt.source.Name(), func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := tt.source.GetVersions(ctx, fmt.Sprintf(tt.purlFmt, "missing")); !errors.Is(err, ErrPackageNotFound) {
+				t.Errorf("expected ErrPackageNotFound for unknown package, got: %v", err)
+			}
+			if _, err := tt.source.GetVersions(ctx, fmt.Sprintf(tt.purlFmt, "broken")); err == nil || errors.Is(err, ErrPackageNotFound) {
+				t.Errorf("expected server error not to count as not found, got: %v", err)
+			}
+			if _, err := tt.source.GetVersions(ctx, fmt.Sprintf(tt.purlFmt, "garbled")); err == nil || errors.Is(err, ErrPackageNotFound) {
+				t.Errorf("expected error for invalid response, got: %v", err)
+			}
+			if _, err := tt.source.GetVersions(ctx, "pkg:npm/left-pad@1.0.0"); !errors.Is(err, ErrUnsupportedPackageType) {
+				t.Errorf("expected ErrUnsupportedPackageType for npm package, got: %v", err)
+			}
+			if _, err := tt.source.GetVersions(ctx, "not a purl"); !errors.Is(err, ErrInvalidPURL) {
+				t.Errorf("expected ErrInvalidPURL, got: %v", err)
+			}
+		})
+	}
+}
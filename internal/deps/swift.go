@@ -0,0 +1,170 @@
+package deps
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// swiftTagPattern matches the version tags SwiftPM resolves: an optional "v" prefix and
+// one to three numeric components with optional pre-release and build metadata
+var swiftTagPattern = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?([-+].*)?$`)
+
+// SwiftSource reads the versions of Swift packages from the tags of their git repositories
+// using the git smart HTTP protocol version 2. Tag dates come from a packfile holding only
+// the tagged commits and annotated tags, so the server must support partial clone filters.
+type SwiftSource struct {
+	mirrorURL  string
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewSwiftSource creates a Swift package source. Repositories are fetched from the host
+// named in the PURL unless mirrorURL is set, in which case <mirrorURL>/<host>/<path>.git is
+// used. A nil httpClient uses default settings.
+func NewSwiftSource(mirrorURL string, httpClient *http.Client, logger *slog.Logger) *SwiftSource {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &SwiftSource{
+		mirrorURL:  strings.TrimSuffix(mirrorURL, "/"),
+		httpClient: defaultHTTPClient(httpClient),
+		logger:     logger,
+	}
+}
+
+// Name identifies git tags of Swift packages as a version source
+func (s *SwiftSource) Name() string {
+	return "swift"
+}
+
+// GetVersions returns the version tags of a Swift package's repository with their dates.
+// Tags that SwiftPM does not treat as versions are skipped.
+func (s *SwiftSource) GetVersions(ctx context.Context, rawPURL string) (*APIResponse, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if purl.Type != packageurl.TypeSwift {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackageType, purl.Type)
+	}
+
+	repositoryURL := "https://" + purl.Namespace + "/" + purl.Name + ".git"
+	if s.mirrorURL != "" {
+		repositoryURL = s.mirrorURL + "/" + purl.Namespace + "/" + purl.Name + ".git"
+	}
+	s.logger.Debug("Listing swift package tags", "purl", rawPURL, "url", repositoryURL)
+
+	var refs []gitRef
+	err = s.upload(ctx, repositoryURL, gitCommandRequest("ls-refs", "peel", "ref-prefix refs/tags/"), func(r io.Reader) (err error) {
+		refs, err = parseLsRefs(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Several tags may denote the same version, e.g. 1.0 and 1.0.0; the first one wins
+	tags := make(map[string]gitRef)
+	var order []string
+	for _, ref := range refs {
+		tag := strings.TrimPrefix(ref.name, "refs/tags/")
+		version, ok := swiftTagVersion(tag)
+		if !ok {
+			continue
+		}
+		if _, seen := tags[version]; !seen {
+			tags[version] = ref
+			order = append(order, version)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("%w: no version tags in %s", ErrPackageNotFound, repositoryURL)
+	}
+
+	dates := s.getTagDates(ctx, repositoryURL, tags)
+
+	versions := make([]VersionsAPIResponse, 0, len(order))
+	for _, version := range order {
+		ref := tags[version]
+		v := Version{Version: version}
+		if date, ok := dates[ref.oid]; ok {
+			v.PublishedAt = date.Format(time.RFC3339)
+		} else if date, ok := dates[ref.peeled]; ok {
+			v.PublishedAt = date.Format(time.RFC3339)
+		}
+		versions = append(versions, VersionsAPIResponse{Version: v})
+	}
+	slices.SortFunc(versions, func(a, b VersionsAPIResponse) int {
+		return strings.Compare(a.Version.PublishedAt, b.Version.PublishedAt)
+	})
+
+	s.logger.Debug("Successfully retrieved swift package versions", "url", repositoryURL, "count", len(versions), "dated", len(dates))
+
+	return &APIResponse{
+		Versions: versions,
+		Metadata: map[string]string{"repository": repositoryURL},
+	}, nil
+}
+
+// getTagDates fetches the tag objects and tagged commits without trees and blobs and returns
+// their dates. Failures are logged and yield no dates, leaving the versions usable for distance.
+func (s *SwiftSource) getTagDates(ctx context.Context, repositoryURL string, tags map[string]gitRef) map[string]time.Time {
+	args := []string{"no-progress", "deepen 1", "filter tree:0"}
+	for _, ref := range tags {
+		args = append(args, "want "+ref.oid)
+	}
+	args = append(args, "done")
+
+	var dates map[string]time.Time
+	err := s.upload(ctx, repositoryURL, gitCommandRequest("fetch", args...), func(r io.Reader) (err error) {
+		pack, err := readFetchPackfile(r)
+		if err != nil {
+			return err
+		}
+		dates, err = readPackObjectDates(pack)
+		return err
+	})
+	if err != nil {
+		s.logger.Debug("Failed to fetch tag dates", "url", repositoryURL, "error", err)
+		return nil
+	}
+	return dates
+}
+
+// upload sends a protocol v2 command to the git-upload-pack service of a repository
+func (s *SwiftSource) upload(ctx context.Context, repositoryURL string, request []byte, decode func(io.Reader) error) error {
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-git-upload-pack-request")
+	header.Set("Accept", "application/x-git-upload-pack-result")
+	header.Set("Git-Protocol", "version=2")
+
+	return send(ctx, s.httpClient, s.logger, http.MethodPost, repositoryURL+"/git-upload-pack", header, bytes.NewReader(request), decode)
+}
+
+// swiftTagVersion returns the version a tag denotes for SwiftPM, completing missing minor
+// and patch components with zeros
+func swiftTagVersion(tag string) (string, bool) {
+	m := swiftTagPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return "", false
+	}
+	minor, patch := m[2], m[3]
+	if minor == "" {
+		minor = "0"
+	}
+	if patch == "" {
+		patch = "0"
+	}
+	return m[1] + "." + minor + "." + patch + m[4], true
+}
@@ -0,0 +1,127 @@
+package deps
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testGitObject is a loose git object for building packfiles
+type testGitObject struct {
+	objectType int
+	typeName   string
+	data       string
+}
+
+func (o testGitObject) oid() string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s %d\x00%s", o.typeName, len(o.data), o.data)))
+	return hex.EncodeToString(sum[:])
+}
+
+// buildTestPack encodes objects as an undeltified version 2 packfile
+func buildTestPack(t *testing.T, objects ...testGitObject) []byte {
+	t.Helper()
+	var pack bytes.Buffer
+	pack.WriteString("PACK")
+	_ = binary.Write(&pack, binary.BigEndian, uint32(2))
+	_ = binary.Write(&pack, binary.BigEndian, uint32(len(objects)))
+
+	for _, o := range objects {
+		size := len(o.data)
+		b := byte(o.objectType<<4) | byte(size&0x0f)
+		size >>= 4
+		for size > 0 {
+			pack.WriteByte(b | 0x80)
+			b = byte(size & 0x7f)
+			size >>= 7
+		}
+		pack.WriteByte(b)
+
+		zw := zlib.NewWriter(&pack)
+		if _, err := zw.Write([]byte(o.data)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return pack.Bytes()
+}
+
+func TestSwiftSource(t *testing.T) {
+	commitA := testGitObject{gitObjectCommit, "commit", "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author Dev <dev@example.com> 1600000000 +0000\ncommitter Dev <dev@example.com> 1600000000 +0000\n\nfirst release\n"}
+	commitB := testGitObject{gitObjectCommit, "commit", "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"author Dev <dev@example.com> 1650000000 +0000\ncommitter Dev <dev@example.com> 1650000000 +0000\n\nsecond release\n"}
+	tagB := testGitObject{gitObjectTag, "tag", "object " + commitB.oid() + "\ntype commit\ntag v1.1\n" +
+		"tagger Dev <dev@example.com> 1660000000 +0200\n\nrelease 1.1\n"}
+	pack := buildTestPack(t, commitA, tagB, commitB)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/apple/swift-nio.git/git-upload-pack" || r.Header.Get("Git-Protocol") != "version=2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case bytes.Contains(body, []byte("command=ls-refs")):
+			_, _ = io.WriteString(w, pktLine(commitA.oid()+" refs/tags/1.0\n")+
+				pktLine(tagB.oid()+" refs/tags/v1.1 peeled:"+commitB.oid()+"\n")+
+				pktLine(commitB.oid()+" refs/tags/nightly\n")+"0000")
+		case bytes.Contains(body, []byte("command=fetch")) && bytes.Contains(body, []byte("filter tree:0")):
+			var resp strings.Builder
+			resp.WriteString(pktLine("packfile\n"))
+			// Split the pack over several side-band packets
+			for chunk := range slices.Chunk(pack, 64) {
+				resp.WriteString(pktLine("\x01" + string(chunk)))
+			}
+			resp.WriteString("0000")
+			_, _ = io.WriteString(w, resp.String())
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := NewSwiftSource(server.URL, nil, nil).GetVersions(context.Background(), "pkg:swift/github.com/apple/swift-nio@1.0.0")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 2 {
+		t.Fatalf("expected 2 version tags, got %+v", resp.Versions)
+	}
+
+	first, second := resp.Versions[0].Version, resp.Versions[1].Version
+	if first.Version != "1.0.0" || first.PublishedAt != time.Unix(1600000000, 0).UTC().Format(time.RFC3339) {
+		t.Errorf("expected commit date for lightweight tag, got %+v", first)
+	}
+	if second.Version != "1.1.0" || second.PublishedAt != time.Unix(1660000000, 0).UTC().Format(time.RFC3339) {
+		t.Errorf("expected tagger date for annotated tag, got %+v", second)
+	}
+}
+
+func TestSwiftTagVersion(t *testing.T) {
+	for tag, want := range map[string]string{
+		"1.2.3":        "1.2.3",
+		"v2.0":         "2.0.0",
+		"3":            "3.0.0",
+		"1.0.0-beta.1": "1.0.0-beta.1",
+		"release-1.0":  "",
+	} {
+		got, ok := swiftTagVersion(tag)
+		if ok != (want != "") || got != want {
+			t.Errorf("swiftTagVersion(%q) = %q, %v; want %q", tag, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package deps
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCertPEM writes the TLS test server's certificate to a PEM file
+func writeCertPEM(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewHTTPClientTrustsCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testDepsDevResponse))
+	}))
+	defer server.Close()
+
+	untrusted := NewClient(nil, WithBaseURL(server.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	if _, err := untrusted.GetVersions(context.Background(), "pkg:npm/vue@3.5.17"); err == nil {
+		t.Fatal("expected certificate error without CA bundle")
+	}
+
+	httpClient, err := NewHTTPClient(TransportConfig{CAFile: writeCertPEM(t, server)})
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	client := NewClient(nil, WithHTTPClient(httpClient), WithBaseURL(server.URL))
+	resp, err := client.GetVersions(context.Background(), "pkg:npm/vue@3.5.17")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(resp.Versions) != 1 {
+		t.Errorf("expected 1 version, got %d", len(resp.Versions))
+	}
+}
+
+func TestNewHTTPClientSendsClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(testDepsDevResponse))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// reuse the server's key pair as client certificate
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	cert := server.TLS.Certificates[0]
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient, err := NewHTTPClient(TransportConfig{CAFile: writeCertPEM(t, server), CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	client := NewClient(nil, WithHTTPClient(httpClient), WithBaseURL(server.URL))
+	if _, err := client.GetVersions(context.Background(), "pkg:npm/vue@3.5.17"); err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+}
+
+func TestNewTransportRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config TransportConfig
+	}{
+		{"proxy without host", TransportConfig{ProxyURL: "not a url"}},
+		{"missing CA file", TransportConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"cert without key", TransportConfig{CertFile: "client.pem"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTransport(tt.config); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestClientSendsHeadersToBaseURL(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(testDepsDevResponse))
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	client := NewClient(nil, WithBaseURL(server.URL+"/mirror/v3/"), WithHeaders(header))
+	if _, err := client.GetVersions(context.Background(), "pkg:npm/vue@3.5.17"); err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	if gotPath != "/mirror/v3/systems/npm/packages/vue" {
+		t.Errorf("unexpected path %q", gotPath)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("unexpected Authorization header %q", gotAuth)
+	}
+}
@@ -0,0 +1,114 @@
+package deps
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// VersionInfo holds deps.dev details about a single package version
+type VersionInfo struct {
+	Version          string   `json:"version"`
+	IsDeprecated     bool     `json:"isDeprecated"`
+	DeprecatedReason string   `json:"deprecatedReason,omitempty"`
+	Licenses         []string `json:"licenses,omitempty"`
+	// Advisories are the IDs of security advisories affecting the version, e.g. GHSA or OSV IDs
+	Advisories []string `json:"advisories,omitempty"`
+}
+
+// versionAPIResponse is the subset of the deps.dev GetVersion response used for VersionInfo
+type versionAPIResponse struct {
+	VersionKey struct {
+		Version string `json:"version"`
+	} `json:"versionKey"`
+	IsDeprecated     bool     `json:"isDeprecated"`
+	DeprecatedReason string   `json:"deprecatedReason"`
+	Licenses         []string `json:"licenses"`
+	AdvisoryKeys     []struct {
+		ID string `json:"id"`
+	} `json:"advisoryKeys"`
+}
+
+// VersionInfoSource provides details about single versions of a package. Name must match
+// the MetadataSource of the responses whose packages the source can describe.
+type VersionInfoSource interface {
+	Name() string
+	GetVersionInfo(ctx context.Context, rawPURL, version string) (*VersionInfo, error)
+}
+
+// GetVersionInfo retrieves deprecation status, licenses and advisories of one version of the
+// package identified by rawPURL. Lookups of the same version share a single request, and the
+// persistent cache is consulted first if the client has one.
+func (c *Client) GetVersionInfo(ctx context.Context, rawPURL, version string) (*VersionInfo, error) {
+	purl, err := packageurl.FromString(rawPURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPURL, rawPURL, err)
+	}
+	if version == "" {
+		return nil, fmt.Errorf("no version given for %s", rawPURL)
+	}
+
+	name, system, err := getNameAndSystem(purl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract name and system from PURL: %w", err)
+	}
+
+	info, shared, err := c.infoFlight.do(ctx, versionInfoKey(system, name, version), func() (*VersionInfo, error) {
+		if c.cache != nil {
+			if cached, ok := c.cache.GetVersionInfo(c.baseURL, system, name, version); ok {
+				return cached, nil
+			}
+		}
+
+		apiURL := fmt.Sprintf("%s/systems/%s/packages/%s/versions/%s", c.baseURL, system, name, url.PathEscape(version))
+		c.logger.Debug("Fetching version details", "url", apiURL)
+
+		var resp versionAPIResponse
+		if err := c.requestWithRetry(ctx, purl.String(), apiURL, nil, &resp); err != nil {
+			return nil, err
+		}
+
+		info := resp.versionInfo(version)
+		c.cacheVersionInfo(system, name, version, info)
+		return info, nil
+	})
+	if shared {
+		c.infoDeduplicated.Add(1)
+	}
+
+	return info, err
+}
+
+// cacheVersionInfo stores fetched details in the persistent cache if the client has one
+func (c *Client) cacheVersionInfo(system, name, version string, info *VersionInfo) {
+	if c.cache == nil {
+		return
+	}
+	if err := c.cache.PutVersionInfo(c.baseURL, system, name, version, info); err != nil {
+		c.logger.Warn("Failed to cache version details", "system", system, "name", name, "version", version, "error", err)
+	}
+}
+
+// versionInfo converts a GetVersion response for the requested version
+func (r *versionAPIResponse) versionInfo(requested string) *VersionInfo {
+	info := &VersionInfo{
+		Version:          r.VersionKey.Version,
+		IsDeprecated:     r.IsDeprecated,
+		DeprecatedReason: r.DeprecatedReason,
+		Licenses:         r.Licenses,
+	}
+	if info.Version == "" {
+		info.Version = requested
+	}
+	for _, advisory := range r.AdvisoryKeys {
+		info.Advisories = append(info.Advisories, advisory.ID)
+	}
+	return info
+}
+
+// versionInfoKey identifies a version among the lookups of a client
+func versionInfoKey(system, name, version string) string {
+	return cacheKey(system, name) + "@" + version
+}
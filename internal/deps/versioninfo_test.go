@@ -0,0 +1,113 @@
+package deps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientGetVersionInfo(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/systems/npm/packages/request/versions/2.88.2":
+			_, _ = w.Write([]byte(`{
+				"versionKey": {"system": "NPM", "name": "request", "version": "2.88.2"},
+				"isDeprecated": true,
+				"deprecatedReason": "request has been deprecated",
+				"licenses": ["Apache-2.0"],
+				"advisoryKeys": [{"id": "GHSA-p8p7-x288-28g6"}]
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	info, err := client.GetVersionInfo(context.Background(), "pkg:npm/request@2.88.2", "2.88.2")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if !info.IsDeprecated || info.DeprecatedReason == "" {
+		t.Errorf("expected deprecated version, got %+v", info)
+	}
+	if !slices.Equal(info.Licenses, []string{"Apache-2.0"}) || !slices.Equal(info.Advisories, []string{"GHSA-p8p7-x288-28g6"}) {
+		t.Errorf("unexpected licenses or advisories: %+v", info)
+	}
+
+	// A second lookup of the same version is served without a request
+	if _, err := client.GetVersionInfo(context.Background(), "pkg:npm/request@2.88.2", "2.88.2"); err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", calls.Load())
+	}
+
+	if _, err := client.GetVersionInfo(context.Background(), "pkg:npm/request@2.88.2", "9.9.9"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("expected ErrPackageNotFound for unknown version, got: %v", err)
+	}
+}
+
+func TestClientMarksVersionsSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testDepsDevResponse))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	resp, err := client.GetVersions(context.Background(), "pkg:npm/vue@3.5.17")
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if source := resp.Metadata[MetadataSource]; source != client.Name() {
+		t.Errorf("expected source %q, got %q", client.Name(), source)
+	}
+}
+
+func TestClientCachesVersionInfo(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"versionKey": {"version": "2.88.2"}, "licenses": ["Apache-2.0"]}`))
+	}))
+	defer server.Close()
+
+	cache, err := NewCache(t.TempDir(), time.Hour, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	// Each client stands for a run; the second one is served from disk
+	for range 2 {
+		client := newTestClient(server, WithCache(cache))
+		info, err := client.GetVersionInfo(context.Background(), "pkg:npm/request@2.88.2", "2.88.2")
+		if err != nil {
+			t.Fatalf("no error expected, got: %v", err)
+		}
+		if !slices.Equal(info.Licenses, []string{"Apache-2.0"}) {
+			t.Errorf("unexpected version details: %+v", info)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", calls.Load())
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected 1 cache hit and 1 miss, got %+v", stats)
+	}
+
+	// Prefetching skips versions in the cache
+	client := newTestClient(server, WithCache(cache), WithBaseURL(server.URL+"/v3"))
+	if err := client.PrefetchVersionInfo(context.Background(), []VersionInfoRequest{{PURL: "pkg:npm/request@2.88.2", Version: "2.88.2"}}); err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected no batch request for cached versions, got %d requests", calls.Load())
+	}
+}
@@ -0,0 +1,161 @@
+package semver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// errInvalidComposerVersion is returned for versions Composer cannot normalize, including
+// branches such as dev-main or 2.x-dev
+var errInvalidComposerVersion = errors.New("invalid Composer version")
+
+// composerVersionPattern is the classical version pattern of Composer's VersionParser: up to
+// four numbers, an optional stability with number and an optional dev suffix
+var composerVersionPattern = regexp.MustCompile(`(?i)^v?(\d{1,5})(\.\d+)?(\.\d+)?(\.\d+)?` +
+	`[._-]?(?:(stable|beta|b|rc|alpha|a|patch|pl|p)((?:[.-]?\d+)*)?)?` +
+	`([.-]?dev)?$`)
+
+// composerStabilities ranks the normalized stabilities; patch releases follow the release
+var composerStabilities = map[string]int{"dev": 0, "alpha": 1, "beta": 2, "RC": 3, "": 4, "patch": 5}
+
+// composerScheme orders PHP package versions like Composer, which normalizes them to four
+// numbers and a stability, e.g. 1.0.0-p1 to 1.0.0.0-patch1, a release after 1.0.0
+type composerScheme struct{}
+
+func (composerScheme) Name() string {
+	return "composer"
+}
+
+func (composerScheme) Parse(rawVersion string) (Version, error) {
+	m := composerVersionPattern.FindStringSubmatch(strings.TrimSpace(rawVersion))
+	if m == nil {
+		return nil, errInvalidComposerVersion
+	}
+
+	v := composerVersion{original: rawVersion}
+	for i := range v.release {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimPrefix(m[i+1], "."), 10, 64)
+		if err != nil {
+			return nil, errInvalidComposerVersion
+		}
+		v.release[i] = n
+	}
+
+	switch strings.ToLower(m[5]) {
+	case "a", "alpha":
+		v.stability = "alpha"
+	case "b", "beta":
+		v.stability = "beta"
+	case "rc":
+		v.stability = "RC"
+	case "p", "pl", "patch":
+		v.stability = "patch"
+	}
+	if v.stability != "" {
+		for part := range strings.FieldsFuncSeq(m[6], func(r rune) bool { return r == '.' || r == '-' }) {
+			n, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return nil, errInvalidComposerVersion
+			}
+			v.number = append(v.number, n)
+		}
+	}
+	if m[7] != "" {
+		if v.stability == "" {
+			v.stability = "dev"
+		} else {
+			v.dev = true
+		}
+	}
+
+	return v, nil
+}
+
+// composerVersion is a normalized Composer version
+type composerVersion struct {
+	original  string
+	release   [4]int64
+	stability string
+	number    []int64
+	// dev marks the development version of a pre-release or patch, e.g. 1.0.0-beta2-dev
+	dev bool
+}
+
+// Compare orders versions like PHP's version_compare orders Composer's normalized versions:
+// by release, stability, stability number, and with a dev suffix before the version without
+func (v composerVersion) Compare(other Version) int {
+	o, ok := other.(composerVersion)
+	if !ok {
+		panic(fmt.Sprintf("cannot compare Composer version %s with %T", v, other))
+	}
+
+	if c := compareSegments(v.release[:], o.release[:]); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(composerStabilities[v.stability], composerStabilities[o.stability]); c != 0 {
+		return c
+	}
+	if c := compareSegments(v.number, o.number); c != 0 {
+		return c
+	}
+	switch {
+	case v.dev && !o.dev:
+		return -1
+	case !v.dev && o.dev:
+		return 1
+	}
+	return 0
+}
+
+// Prerelease returns the dev, alpha, beta or RC stability with its number, e.g. "beta2".
+// Patch releases are releases.
+func (v composerVersion) Prerelease() string {
+	if v.stability == "" || v.stability == "patch" {
+		return ""
+	}
+	return strings.TrimPrefix(v.suffix(), "-")
+}
+
+// Segments64 returns the four numbers, followed by the number of a patch release, so that
+// 1.0.0-p1 counts as a revision of 1.0.0
+func (v composerVersion) Segments64() []int64 {
+	segments := v.release[:]
+	if v.stability == "patch" {
+		segments = append(segments, v.number...)
+	}
+	return segments
+}
+
+func (v composerVersion) Original() string {
+	return v.original
+}
+
+// String returns the normalized form, e.g. 1.0.0.0-beta2
+func (v composerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v.release[0], v.release[1], v.release[2], v.release[3]) + v.suffix()
+}
+
+// suffix returns the normalized stability suffix
+func (v composerVersion) suffix() string {
+	if v.stability == "" {
+		return ""
+	}
+	s := "-" + v.stability
+	for i, n := range v.number {
+		if i > 0 {
+			s += "."
+		}
+		s += strconv.FormatInt(n, 10)
+	}
+	if v.dev {
+		s += "-dev"
+	}
+	return s
+}
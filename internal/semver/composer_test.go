@@ -0,0 +1,56 @@
+package semver
+
+import (
+	"testing"
+)
+
+func TestComposerOrdering(t *testing.T) {
+	// The stability order of Composer: dev < alpha < beta < RC < stable < patch
+	assertAscending(t, Composer, []string{
+		"1.0.0-dev", "1.0.0-alpha", "1.0.0-alpha1", "1.0.0-alpha2", "v1.0.0-beta1-dev", "1.0.0-beta1", "1.0.0-b2",
+		"1.0.0-RC1", "1.0.0-rc.2", "1.0.0", "1.0.0-p1", "1.0.0-patch2", "1.0.0.1", "1.0.1", "1.10", "2.0.0-dev",
+	})
+
+	for _, equal := range [][2]string{
+		{"1.0", "1.0.0.0"},
+		{"v1.2.3", "1.2.3"},
+		{"1.0.0-stable", "1.0.0"},
+		{"1.0.0-pl1", "1.0.0-patch1"},
+		{"1.0.0-a1", "1.0.0-alpha.1"},
+	} {
+		a, _ := Composer.Parse(equal[0])
+		b, _ := Composer.Parse(equal[1])
+		if a == nil || b == nil || a.Compare(b) != 0 {
+			t.Errorf("expected %s == %s", equal[0], equal[1])
+		}
+	}
+
+	// Branches are not releases
+	for _, raw := range []string{"", "dev-main", "2.x-dev", "1.0.0-foo", "latest"} {
+		if _, err := Composer.Parse(raw); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+}
+
+func TestComposerVersionDistance(t *testing.T) {
+	if SchemeFor("composer") != Composer {
+		t.Fatal("expected Composer ordering for composer components")
+	}
+
+	versions := []string{"dev-main", "1.0.0", "1.0.0-p1", "1.0.1-RC1", "1.0.1", "2.x-dev"}
+
+	// Patch releases are missed revisions; pre-releases and branches are skipped
+	distance, err := GetVersionDistance("1.0.0", versions, WithScheme(Composer))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *distance != (VersionDistance{MissedReleases: 2, MissedPatch: 1, MissedRevision: 1}) {
+		t.Errorf("expected 1.0.0-p1 and 1.0.1 to be missed, got %+v", *distance)
+	}
+
+	v, _ := Composer.Parse("v1.0.0-beta.2-dev")
+	if v.String() != "1.0.0.0-beta2-dev" || v.Prerelease() != "beta2-dev" {
+		t.Errorf("unexpected normalization %s, prerelease %q", v, v.Prerelease())
+	}
+}
@@ -0,0 +1,452 @@
+package semver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// errInvalidDistroVersion is returned for versions that do not follow a distro's version format
+var errInvalidDistroVersion = errors.New("invalid version format")
+
+// distroVersion is a version of a distro package. Packages in a distro index are releases
+// of the distro even if their upstream version is a prerelease, so none is a prerelease.
+type distroVersion struct {
+	original string
+	epoch    int64
+	segments []int64
+}
+
+func (v distroVersion) Prerelease() string {
+	return ""
+}
+
+func (v distroVersion) Epoch() int64 {
+	return v.epoch
+}
+
+func (v distroVersion) Segments64() []int64 {
+	return v.segments
+}
+
+func (v distroVersion) Original() string {
+	return v.original
+}
+
+func (v distroVersion) String() string {
+	return v.original
+}
+
+// splitEpoch splits an optional numeric "epoch:" prefix off a distro version
+func splitEpoch(rawVersion string) (int64, string, error) {
+	prefix, rest, found := strings.Cut(rawVersion, ":")
+	if !found {
+		return 0, rawVersion, nil
+	}
+	epoch, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || epoch < 0 {
+		return 0, "", fmt.Errorf("%w: epoch %q is not a number", errInvalidDistroVersion, prefix)
+	}
+	return epoch, rest, nil
+}
+
+// splitRevision splits the part after the last hyphen off a distro version
+func splitRevision(rawVersion string) (string, string) {
+	if i := strings.LastIndexByte(rawVersion, '-'); i >= 0 {
+		return rawVersion[:i], rawVersion[i+1:]
+	}
+	return rawVersion, ""
+}
+
+// leadingSegments returns the dot-separated numbers at the start of a version, e.g. [2 36]
+// for "2.36+dfsg"
+func leadingSegments(version string) []int64 {
+	var segments []int64
+	for part := range strings.SplitSeq(version, ".") {
+		end := 0
+		for end < len(part) && isDigit(part[end]) {
+			end++
+		}
+		n, err := strconv.ParseInt(part[:end], 10, 64)
+		if err != nil {
+			break
+		}
+		segments = append(segments, n)
+		if end < len(part) {
+			break
+		}
+	}
+	return segments
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// dpkgScheme implements the Debian version ordering of dpkg --compare-versions
+type dpkgScheme struct{}
+
+func (dpkgScheme) Name() string {
+	return "dpkg"
+}
+
+func (dpkgScheme) Parse(rawVersion string) (Version, error) {
+	epoch, rest, err := splitEpoch(rawVersion)
+	if err != nil {
+		return nil, err
+	}
+	upstream, revision := splitRevision(rest)
+	if upstream == "" || !isDigit(upstream[0]) {
+		return nil, fmt.Errorf("%w: upstream version must start with a digit", errInvalidDistroVersion)
+	}
+
+	return dpkgVersion{
+		distroVersion: distroVersion{original: rawVersion, epoch: epoch, segments: leadingSegments(upstream)},
+		upstream:      upstream,
+		revision:      revision,
+	}, nil
+}
+
+// dpkgVersion is a parsed [epoch:]upstream[-revision] Debian version
+type dpkgVersion struct {
+	distroVersion
+	upstream string
+	revision string
+}
+
+func (v dpkgVersion) Compare(other Version) int {
+	if c := v.CompareUpstream(other); c != 0 {
+		return c
+	}
+	return dpkgCompare(v.revision, other.(dpkgVersion).revision)
+}
+
+// CompareUpstream compares epoch and upstream version, ignoring the Debian revision
+func (v dpkgVersion) CompareUpstream(other Version) int {
+	o, ok := other.(dpkgVersion)
+	if !ok {
+		panic(fmt.Sprintf("cannot compare dpkg version %s with %T", v, other))
+	}
+	if c := cmp.Compare(v.epoch, o.epoch); c != 0 {
+		return c
+	}
+	return dpkgCompare(v.upstream, o.upstream)
+}
+
+// dpkgOrder weights a character of a non-digit part: '~' sorts before everything, even the
+// end of the part, and letters sort before all other characters
+func dpkgOrder(s string) int {
+	switch {
+	case s == "" || isDigit(s[0]):
+		return 0
+	case isAlpha(s[0]):
+		return int(s[0])
+	case s[0] == '~':
+		return -1
+	default:
+		return int(s[0]) + 256
+	}
+}
+
+// dpkgCompare compares upstream versions or revisions by alternating non-digit parts, compared
+// by character weight, and digit parts, compared numerically
+func dpkgCompare(a, b string) int {
+	for a != "" || b != "" {
+		for a != "" && !isDigit(a[0]) || b != "" && !isDigit(b[0]) {
+			if c := cmp.Compare(dpkgOrder(a), dpkgOrder(b)); c != 0 {
+				return c
+			}
+			a, b = a[1:], b[1:]
+		}
+
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		firstDiff := 0
+		for a != "" && isDigit(a[0]) && b != "" && isDigit(b[0]) {
+			if firstDiff == 0 {
+				firstDiff = cmp.Compare(a[0], b[0])
+			}
+			a, b = a[1:], b[1:]
+		}
+		if a != "" && isDigit(a[0]) {
+			return 1
+		}
+		if b != "" && isDigit(b[0]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// rpmScheme implements the RPM version ordering of rpmvercmp
+type rpmScheme struct{}
+
+func (rpmScheme) Name() string {
+	return "rpm"
+}
+
+func (rpmScheme) Parse(rawVersion string) (Version, error) {
+	epoch, rest, err := splitEpoch(rawVersion)
+	if err != nil {
+		return nil, err
+	}
+	version, release := splitRevision(rest)
+	if version == "" {
+		return nil, fmt.Errorf("%w: empty version", errInvalidDistroVersion)
+	}
+
+	return rpmVersion{
+		distroVersion: distroVersion{original: rawVersion, epoch: epoch, segments: leadingSegments(version)},
+		version:       version,
+		release:       release,
+	}, nil
+}
+
+// rpmVersion is a parsed [epoch:]version[-release] RPM version
+type rpmVersion struct {
+	distroVersion
+	version string
+	release string
+}
+
+func (v rpmVersion) Compare(other Version) int {
+	if c := v.CompareUpstream(other); c != 0 {
+		return c
+	}
+	return rpmvercmp(v.release, other.(rpmVersion).release)
+}
+
+// CompareUpstream compares epoch and version, ignoring the release
+func (v rpmVersion) CompareUpstream(other Version) int {
+	o, ok := other.(rpmVersion)
+	if !ok {
+		panic(fmt.Sprintf("cannot compare rpm version %s with %T", v, other))
+	}
+	if c := cmp.Compare(v.epoch, o.epoch); c != 0 {
+		return c
+	}
+	return rpmvercmp(v.version, o.version)
+}
+
+// rpmvercmp compares alternating numeric and alphabetic segments, ignoring separators. A
+// tilde sorts before everything and a caret before everything but the end of the version.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	isSeparator := func(s string) bool {
+		return s != "" && !isDigit(s[0]) && !isAlpha(s[0]) && s[0] != '~' && s[0] != '^'
+	}
+	for a != "" || b != "" {
+		for isSeparator(a) {
+			a = a[1:]
+		}
+		for isSeparator(b) {
+			b = b[1:]
+		}
+
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			switch {
+			case a == "":
+				return -1
+			case b == "":
+				return 1
+			case a[0] != '^':
+				return 1
+			case b[0] != '^':
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		numeric := isDigit(a[0])
+		inSegment := isAlpha
+		if numeric {
+			inSegment = isDigit
+		}
+		segA, segB := a, b
+		a = strings.TrimLeftFunc(a, func(r rune) bool { return r < 128 && inSegment(byte(r)) })
+		b = strings.TrimLeftFunc(b, func(r rune) bool { return r < 128 && inSegment(byte(r)) })
+		segA, segB = segA[:len(segA)-len(a)], segB[:len(segB)-len(b)]
+
+		// Segments of different types: numeric ones are newer
+		if segB == "" {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		if numeric {
+			segA, segB = strings.TrimLeft(segA, "0"), strings.TrimLeft(segB, "0")
+			if c := cmp.Compare(len(segA), len(segB)); c != 0 {
+				return c
+			}
+		}
+		if c := strings.Compare(segA, segB); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+// apkSuffixes ranks the suffixes of Alpine versions. Suffixes ranked below zero mark upstream
+// prereleases and sort before the version without suffix.
+var apkSuffixes = map[string]int{
+	"alpha": -4,
+	"beta":  -3,
+	"pre":   -2,
+	"rc":    -1,
+	"cvs":   1,
+	"svn":   2,
+	"git":   3,
+	"hg":    4,
+	"p":     5,
+}
+
+// apkScheme implements the Alpine version ordering of apk-tools
+type apkScheme struct{}
+
+func (apkScheme) Name() string {
+	return "apk"
+}
+
+func (apkScheme) Parse(rawVersion string) (Version, error) {
+	rest := rawVersion
+	v := apkVersion{distroVersion: distroVersion{original: rawVersion}}
+
+	if i := strings.LastIndex(rest, "-r"); i >= 0 {
+		revision, err := strconv.ParseInt(rest[i+2:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: revision %q is not a number", errInvalidDistroVersion, rest[i+2:])
+		}
+		v.revision = revision
+		rest = rest[:i]
+	}
+
+	parts := strings.Split(rest, "_")
+	for _, suffix := range parts[1:] {
+		name := strings.TrimRightFunc(suffix, func(r rune) bool { return r >= '0' && r <= '9' })
+		rank, ok := apkSuffixes[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown suffix %q", errInvalidDistroVersion, suffix)
+		}
+		var number int64
+		if digits := suffix[len(name):]; digits != "" {
+			number, _ = strconv.ParseInt(digits, 10, 64)
+		}
+		v.suffixes = append(v.suffixes, apkSuffix{rank: rank, number: number})
+	}
+
+	numbers := parts[0]
+	if n := len(numbers); n > 1 && numbers[n-1] >= 'a' && numbers[n-1] <= 'z' {
+		v.letter = numbers[n-1]
+		numbers = numbers[:n-1]
+	}
+	for part := range strings.SplitSeq(numbers, ".") {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 || part[0] == '+' {
+			return nil, fmt.Errorf("%w: %q is not a number", errInvalidDistroVersion, part)
+		}
+		v.segments = append(v.segments, n)
+	}
+
+	return v, nil
+}
+
+type apkSuffix struct {
+	rank   int
+	number int64
+}
+
+// apkVersion is a parsed Alpine version: numbers, an optional letter, suffixes and the
+// package revision
+type apkVersion struct {
+	distroVersion
+	letter   byte
+	suffixes []apkSuffix
+	revision int64
+}
+
+func (v apkVersion) Compare(other Version) int {
+	if c := v.CompareUpstream(other); c != 0 {
+		return c
+	}
+	return cmp.Compare(v.revision, other.(apkVersion).revision)
+}
+
+// CompareUpstream compares numbers, letter and suffixes, ignoring the package revision
+func (v apkVersion) CompareUpstream(other Version) int {
+	o, ok := other.(apkVersion)
+	if !ok {
+		panic(fmt.Sprintf("cannot compare apk version %s with %T", v, other))
+	}
+	// More numbers are newer: 1.2.1 > 1.2
+	if c := compareSegments(v.segments, o.segments); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.letter, o.letter); c != 0 {
+		return c
+	}
+	for i := range max(len(v.suffixes), len(o.suffixes)) {
+		// A missing suffix ranks like a release, between prerelease and patch suffixes
+		var a, b apkSuffix
+		if i < len(v.suffixes) {
+			a = v.suffixes[i]
+		}
+		if i < len(o.suffixes) {
+			b = o.suffixes[i]
+		}
+		if c := cmp.Compare(a.rank, b.rank); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.number, b.number); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareSegments compares numbers pairwise; if all shared numbers are equal, the longer
+// slice is newer
+func compareSegments(a, b []int64) int {
+	for i := range min(len(a), len(b)) {
+		if c := cmp.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
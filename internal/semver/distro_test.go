@@ -0,0 +1,143 @@
+package semver
+
+import (
+	"sbom-technical-lag/internal/deps"
+	"testing"
+)
+
+func TestDistroSchemeOrdering(t *testing.T) {
+	tests := []struct {
+		scheme Scheme
+		lower  string
+		higher string
+	}{
+		{Dpkg, "1.0~rc1-1", "1.0-1"},
+		{Dpkg, "1.0-1", "1.0+dfsg-1"},
+		{Dpkg, "1.0-1", "1.0-1+deb12u1"},
+		{Dpkg, "2.36-9+deb12u3", "2.36-9+deb12u10"},
+		{Dpkg, "9.9-1", "1:1.0-1"},
+		{Dpkg, "1.0a", "1.0.1"},
+		{Dpkg, "1.0", "1.0-0.1"},
+		{RPM, "3.0.7-27.el9", "3.0.7-28.el9"},
+		{RPM, "3.0.7-27.el9", "1:3.0.1-1.el9"},
+		{RPM, "1.0~rc1-1", "1.0-1"},
+		{RPM, "1.0-1", "1.0^git1-1"},
+		{RPM, "1.0^git1-1", "1.0.1-1"},
+		{RPM, "1.0a-1", "1.0.1-1"},
+		{RPM, "1.9-1", "1.10-1"},
+		{APK, "1.2.3-r0", "1.2.3-r1"},
+		{APK, "1.2.3_rc1-r0", "1.2.3-r0"},
+		{APK, "1.2.3-r5", "1.2.3_p1-r0"},
+		{APK, "1.2-r0", "1.2.1-r0"},
+		{APK, "1.2.3-r0", "1.2.3a-r0"},
+		{APK, "3.1.9-r0", "3.1.10-r0"},
+	}
+
+	for _, tt := range tests {
+		lower, err := tt.scheme.Parse(tt.lower)
+		if err != nil {
+			t.Fatalf("%s: failed to parse %q: %v", tt.scheme.Name(), tt.lower, err)
+		}
+		higher, err := tt.scheme.Parse(tt.higher)
+		if err != nil {
+			t.Fatalf("%s: failed to parse %q: %v", tt.scheme.Name(), tt.higher, err)
+		}
+		if lower.Compare(higher) >= 0 || higher.Compare(lower) <= 0 {
+			t.Errorf("%s: expected %s < %s", tt.scheme.Name(), tt.lower, tt.higher)
+		}
+		if lower.Compare(lower) != 0 {
+			t.Errorf("%s: expected %s to equal itself", tt.scheme.Name(), tt.lower)
+		}
+	}
+
+	for _, equal := range [][2]string{{"1.0-1", "0:1.0-1"}, {"1.01", "1.1"}} {
+		a, _ := Dpkg.Parse(equal[0])
+		b, _ := Dpkg.Parse(equal[1])
+		if a.Compare(b) != 0 {
+			t.Errorf("dpkg: expected %s to equal %s", equal[0], equal[1])
+		}
+	}
+}
+
+func TestDistroSchemeInvalidVersions(t *testing.T) {
+	for scheme, invalid := range map[Scheme]string{
+		Dpkg: "x:1.0",
+		RPM:  "-1",
+		APK:  "1.0_foo1-r0",
+	} {
+		if _, err := scheme.Parse(invalid); err == nil {
+			t.Errorf("%s: expected error for %q", scheme.Name(), invalid)
+		}
+	}
+	if _, err := Dpkg.Parse("abc"); err == nil {
+		t.Error("dpkg: expected error for upstream version without leading digit")
+	}
+}
+
+func TestVersionDistanceWithDpkgScheme(t *testing.T) {
+	versions := []string{"2.36-9+deb12u4", "2.36-9+deb12u1", "2.36-9", "2.37-1", "3.0-1"}
+
+	d, err := GetVersionDistance("2.36-9+deb12u1", versions, WithScheme(Dpkg))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	// The Debian security update only changes the packaging revision
+	if d.MissedReleases != 3 || d.MissedMajor != 1 || d.MissedMinor != 1 || d.MissedPatch != 0 || d.MissedPackaging != 1 {
+		t.Errorf("unexpected distance: %+v", d)
+	}
+
+	newest, err := GetNewestVersion(versions, WithScheme(Dpkg))
+	if err != nil || newest != "3.0-1" {
+		t.Errorf("expected newest version 3.0-1, got %q (%v)", newest, err)
+	}
+
+	// The default scheme cannot parse epochs and misorders Debian revisions
+	if _, err := GetVersionDistance("1:2.36-9", versions); err == nil {
+		t.Error("expected semver to reject a Debian epoch")
+	}
+}
+
+func TestVersionDistancePackagingRevisions(t *testing.T) {
+	tests := []struct {
+		scheme    Scheme
+		used      string
+		versions  []string
+		major     int64
+		patch     int64
+		packaging int64
+	}{
+		{RPM, "1.1.1k-9.el8", []string{"1.1.1k-9.el8", "1.1.1k-12.el8", "1.1.1l-1.el8", "1.1.1l-2.el8"}, 0, 1, 2},
+		{APK, "3.1.4-r0", []string{"3.1.4-r0", "3.1.4-r1", "3.1.5-r0"}, 0, 1, 1},
+		// An epoch bump starts a new major version, even if the version numbers decrease
+		{Dpkg, "1.0-1", []string{"1.0-1", "1.0-2", "1:1.0-1"}, 1, 0, 1},
+		{Dpkg, "9.9-1", []string{"9.9-1", "1:1.0-1"}, 1, 0, 0},
+		{RPM, "2.4-3.el9", []string{"2.4-3.el9", "2.4-4.el9", "1:1.2-1.el9"}, 1, 0, 1},
+	}
+
+	for _, tt := range tests {
+		d, err := GetVersionDistance(tt.used, tt.versions, WithScheme(tt.scheme))
+		if err != nil {
+			t.Fatalf("%s: no error expected, got: %v", tt.scheme.Name(), err)
+		}
+		if d.MissedMajor != tt.major || d.MissedPatch != tt.patch || d.MissedPackaging != tt.packaging {
+			t.Errorf("%s %s: expected %d major, %d patch and %d packaging releases, got %+v",
+				tt.scheme.Name(), tt.used, tt.major, tt.patch, tt.packaging, d)
+		}
+	}
+}
+
+func TestGetLibyearWithRPMScheme(t *testing.T) {
+	versions := []deps.Version{
+		{Version: "3.0.7-28.el9", PublishedAt: "2024-03-01T00:00:00Z"},
+		{Version: "3.0.7-27.el9", PublishedAt: "2024-01-01T00:00:00Z"},
+		{Version: "3.0.7-3.el9", PublishedAt: "2023-01-01T00:00:00Z"},
+	}
+
+	libyear, err := GetLibyear("3.0.7-3.el9", versions, WithScheme(RPM))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if days := libyear.Hours() / 24; days != 425 {
+		t.Errorf("expected 425 days behind 3.0.7-28.el9, got %v", days)
+	}
+}
@@ -0,0 +1,338 @@
+package semver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// errInvalidMavenVersion is returned for empty Maven versions
+var errInvalidMavenVersion = errors.New("invalid Maven version")
+
+// mavenQualifiers are the well-known qualifiers in ascending order; "" is the release.
+// Other qualifiers sort after them, alphabetically.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+// mavenReleaseIndex is the position of the release among the well-known qualifiers
+var mavenReleaseIndex = slices.Index(mavenQualifiers, "")
+
+// mavenQualifierAliases maps qualifiers to their well-known equivalent
+var mavenQualifierAliases = map[string]string{"ga": "", "final": "", "release": "", "cr": "rc"}
+
+// mavenScheme orders Maven versions like Maven's ComparableVersion
+type mavenScheme struct{}
+
+func (mavenScheme) Name() string {
+	return "maven"
+}
+
+// Parse splits a version into numbers and qualifiers at dots, hyphens and transitions between
+// digits and letters. A hyphen or transition starts a sub-list, so "1-rc-2" and "1-rc.2" differ.
+func (mavenScheme) Parse(rawVersion string) (Version, error) {
+	version := strings.ToLower(strings.TrimSpace(rawVersion))
+	if version == "" {
+		return nil, errInvalidMavenVersion
+	}
+
+	root := &mavenList{}
+	list := root
+	stack := []*mavenList{root}
+	startSubList := func() {
+		sub := &mavenList{}
+		list.items = append(list.items, sub)
+		list = sub
+		stack = append(stack, sub)
+	}
+
+	isDigit, start := false, 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.' || c == '-':
+			if i == start {
+				list.items = append(list.items, mavenInt(""))
+			} else {
+				list.items = append(list.items, parseMavenItem(isDigit, version[start:i], false))
+			}
+			start = i + 1
+			if c == '-' {
+				startSubList()
+			}
+		case c >= '0' && c <= '9':
+			if !isDigit && i > start {
+				list.items = append(list.items, parseMavenItem(false, version[start:i], true))
+				start = i
+				startSubList()
+			}
+			isDigit = true
+		default:
+			if isDigit && i > start {
+				list.items = append(list.items, parseMavenItem(true, version[start:i], false))
+				start = i
+				startSubList()
+			}
+			isDigit = false
+		}
+	}
+	if len(version) > start {
+		list.items = append(list.items, parseMavenItem(isDigit, version[start:], false))
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].normalize()
+	}
+
+	return mavenVersion{original: rawVersion, items: root}, nil
+}
+
+// parseMavenItem creates a number or a qualifier. Single letters followed by a digit are
+// short for alpha, beta and milestone, as in "1.0a1" or "3.0.0-M5".
+func parseMavenItem(isDigit bool, s string, followedByDigit bool) mavenItem {
+	if isDigit {
+		return mavenInt(strings.TrimLeft(s, "0"))
+	}
+	if followedByDigit && len(s) == 1 {
+		switch s {
+		case "a":
+			s = "alpha"
+		case "b":
+			s = "beta"
+		case "m":
+			s = "milestone"
+		}
+	}
+	if alias, ok := mavenQualifierAliases[s]; ok {
+		s = alias
+	}
+	return mavenString(s)
+}
+
+// mavenItem is a number, a qualifier or a sub-list of a Maven version. Comparisons with nil
+// compare with the absent item, e.g. 1.0 with the missing third number of 1.0.1.
+type mavenItem interface {
+	compare(other mavenItem) int
+	isNull() bool
+	String() string
+}
+
+// mavenInt is a number without leading zeros, so that numbers of any size can be compared;
+// zero is the empty string
+type mavenInt string
+
+func (i mavenInt) isNull() bool {
+	return i == ""
+}
+
+func (i mavenInt) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		if c := cmp.Compare(len(i), len(o)); c != 0 {
+			return c
+		}
+		return strings.Compare(string(i), string(o))
+	default:
+		// Numbers are newer than qualifiers and sub-lists
+		return 1
+	}
+}
+
+func (i mavenInt) String() string {
+	if i == "" {
+		return "0"
+	}
+	return string(i)
+}
+
+// mavenString is a qualifier with aliases resolved
+type mavenString string
+
+// comparable returns the rank of well-known qualifiers, or the qualifier itself behind all of
+// them, as a string like ComparableVersion does
+func (s mavenString) comparable() string {
+	if i := slices.Index(mavenQualifiers, string(s)); i >= 0 {
+		return strconv.Itoa(i)
+	}
+	return strconv.Itoa(len(mavenQualifiers)) + "-" + string(s)
+}
+
+// known reports whether the qualifier is one of the well-known qualifiers
+func (s mavenString) known() bool {
+	return slices.Contains(mavenQualifiers, string(s))
+}
+
+func (s mavenString) isNull() bool {
+	return s == ""
+}
+
+func (s mavenString) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		return strings.Compare(s.comparable(), strconv.Itoa(mavenReleaseIndex))
+	case mavenString:
+		return strings.Compare(s.comparable(), o.comparable())
+	default:
+		// Qualifiers are older than numbers and sub-lists
+		return -1
+	}
+}
+
+func (s mavenString) String() string {
+	return string(s)
+}
+
+// mavenList is a sequence of items; sub-lists start at hyphens and digit-letter transitions
+type mavenList struct {
+	items []mavenItem
+}
+
+// normalize removes trailing null items, such as the zeros of "1.0.0" or "final", including
+// those before a trailing sub-list
+func (l *mavenList) normalize() {
+	for i := len(l.items) - 1; i >= 0; i-- {
+		item := l.items[i]
+		if item.isNull() {
+			l.items = slices.Delete(l.items, i, i+1)
+		} else if _, isList := item.(*mavenList); !isList {
+			break
+		}
+	}
+}
+
+func (l *mavenList) isNull() bool {
+	return len(l.items) == 0
+}
+
+func (l *mavenList) compare(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(l.items) == 0 {
+			return 0
+		}
+		return l.items[0].compare(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case *mavenList:
+		for i := range max(len(l.items), len(o.items)) {
+			var a, b mavenItem
+			if i < len(l.items) {
+				a = l.items[i]
+			}
+			if i < len(o.items) {
+				b = o.items[i]
+			}
+			var c int
+			if a == nil {
+				c = -b.compare(nil)
+			} else {
+				c = a.compare(b)
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+// String returns the canonical form, with numbers separated by dots and sub-lists by hyphens
+func (l *mavenList) String() string {
+	var b strings.Builder
+	for i, item := range l.items {
+		if i > 0 {
+			if _, isList := item.(*mavenList); isList {
+				b.WriteByte('-')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteString(item.String())
+	}
+	return b.String()
+}
+
+// walk calls fn for all numbers and qualifiers in order
+func (l *mavenList) walk(fn func(mavenItem)) {
+	for _, item := range l.items {
+		if sub, isList := item.(*mavenList); isList {
+			sub.walk(fn)
+		} else {
+			fn(item)
+		}
+	}
+}
+
+// mavenVersion is a parsed Maven version
+type mavenVersion struct {
+	original string
+	items    *mavenList
+}
+
+func (v mavenVersion) Compare(other Version) int {
+	o, ok := other.(mavenVersion)
+	if !ok {
+		panic(fmt.Sprintf("cannot compare maven version %s with %T", v, other))
+	}
+	return v.items.compare(o.items)
+}
+
+// Prerelease returns the first alpha, beta, milestone, rc or snapshot qualifier. Final, GA,
+// release and service packs are releases, as are unknown qualifiers like Guava's "jre".
+func (v mavenVersion) Prerelease() string {
+	var prerelease string
+	v.items.walk(func(item mavenItem) {
+		if s, ok := item.(mavenString); ok && prerelease == "" && s.known() && s.compare(nil) < 0 {
+			prerelease = string(s)
+		}
+	})
+	return prerelease
+}
+
+// Segments64 returns the leading numbers, e.g. [5 3] for "5.3.0.Final"
+func (v mavenVersion) Segments64() []int64 {
+	var segments []int64
+	for _, item := range v.items.items {
+		i, ok := item.(mavenInt)
+		if !ok {
+			break
+		}
+		n, err := strconv.ParseInt(i.String(), 10, 64)
+		if err != nil {
+			break
+		}
+		segments = append(segments, n)
+	}
+	return segments
+}
+
+// ReleaseLine returns the unknown qualifiers, such as "jre" and "android" for Guava, which mark
+// parallel release lines rather than pre-releases
+func (v mavenVersion) ReleaseLine() string {
+	var variants []string
+	v.items.walk(func(item mavenItem) {
+		if s, ok := item.(mavenString); ok && !s.known() {
+			variants = append(variants, string(s))
+		}
+	})
+	return strings.Join(variants, "-")
+}
+
+func (v mavenVersion) Original() string {
+	return v.original
+}
+
+// String returns the canonical form of the version
+func (v mavenVersion) String() string {
+	return v.items.String()
+}
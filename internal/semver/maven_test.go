@@ -0,0 +1,143 @@
+package semver
+
+import (
+	"sbom-technical-lag/internal/deps"
+	"testing"
+)
+
+// assertAscending checks that every version of ordered is lower than all following ones
+func assertAscending(t *testing.T, scheme Scheme, ordered []string) {
+	t.Helper()
+	versions := make([]Version, len(ordered))
+	for i, raw := range ordered {
+		v, err := scheme.Parse(raw)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", raw, err)
+		}
+		versions[i] = v
+	}
+	for i := range versions {
+		for j := i + 1; j < len(versions); j++ {
+			if versions[i].Compare(versions[j]) >= 0 || versions[j].Compare(versions[i]) <= 0 {
+				t.Errorf("expected %s < %s", ordered[i], ordered[j])
+			}
+		}
+	}
+}
+
+func TestMavenOrdering(t *testing.T) {
+	// The qualifier and number orderings tested by Maven's ComparableVersion
+	assertAscending(t, Maven, []string{
+		"1-alpha2snapshot", "1-alpha2", "1-alpha-123", "1-beta-2", "1-beta123", "1-m2", "1-m11", "1-rc",
+		"1-cr2", "1-rc123", "1-SNAPSHOT", "1", "1-sp", "1-sp2", "1-sp123", "1-abc", "1-def", "1-pom-1",
+		"1-1-snapshot", "1-1", "1-2", "1-123",
+	})
+	assertAscending(t, Maven, []string{
+		"2.0", "2-1", "2.0.a", "2.0.0.a", "2.0.2", "2.0.123", "2.1.0", "2.1-a", "2.1b", "2.1-c", "2.1-1", "2.1.0.1",
+		"2.2", "2.123", "11.a2", "11.a11", "11.b2", "11.b11", "11.m2", "11.m11", "11", "11.a", "11b", "11c", "11m",
+	})
+	assertAscending(t, Maven, []string{
+		"3.0.0-M5", "3.0.0-RC1", "3.0.0", "3.0.1", "5.3.0.Final", "5.3.1.Final", "5.4.0-SNAPSHOT", "5.4.0",
+	})
+
+	for _, equal := range [][2]string{
+		{"1", "1.0.0"},
+		{"1.0-ga", "1.0"},
+		{"5.3.0.Final", "5.3.0"},
+		{"1.0.RELEASE", "1.0"},
+		{"1-cr1", "1-rc1"},
+		{"1.0-alpha1", "1.0a1"},
+		{"1.0-M1", "1.0-milestone-1"},
+		{"1.0-SNAPSHOT", "1.0-snapshot"},
+		{"1.0.0-007", "1.0.0-7"},
+	} {
+		a, _ := Maven.Parse(equal[0])
+		b, _ := Maven.Parse(equal[1])
+		if a.Compare(b) != 0 {
+			t.Errorf("expected %s == %s", equal[0], equal[1])
+		}
+	}
+}
+
+func TestMavenClassification(t *testing.T) {
+	for raw, prerelease := range map[string]string{
+		"1.0-SNAPSHOT": "snapshot",
+		"3.0.0-M5":     "milestone",
+		"2.0-RC1":      "rc",
+		"2.0.CR2":      "rc",
+		"1.0-beta-2":   "beta",
+		"5.3.0.Final":  "",
+		"1.0.GA":       "",
+		"1.0-sp1":      "",
+		"31.1-jre":     "",
+		"31.1-android": "",
+	} {
+		v, err := Maven.Parse(raw)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", raw, err)
+		}
+		if v.Prerelease() != prerelease {
+			t.Errorf("%s: expected prerelease %q, got %q", raw, prerelease, v.Prerelease())
+		}
+	}
+
+	v, _ := Maven.Parse("5.3.0.Final")
+	if segments := v.Segments64(); len(segments) != 2 || segments[0] != 5 || segments[1] != 3 {
+		t.Errorf("expected segments [5 3], got %v", segments)
+	}
+	if SchemeFor("maven") != Maven {
+		t.Error("expected Maven ordering for maven components")
+	}
+}
+
+func TestMavenReleaseLines(t *testing.T) {
+	versions := []string{"21.0", "22.0-android", "22.0", "31.0-android", "31.0-jre", "31.1-android", "31.1-jre", "32.0.0-jre-SNAPSHOT"}
+
+	distance, err := GetVersionDistance("31.0-jre", versions, WithScheme(Maven))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *distance != (VersionDistance{MissedReleases: 1, MissedMinor: 1}) {
+		t.Errorf("expected only 31.1-jre to be missed, got %+v", *distance)
+	}
+
+	// Releases before the split into lines belong to every line, later variants count once
+	distance, err = GetVersionDistance("21.0", versions, WithScheme(Maven))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *distance != (VersionDistance{MissedReleases: 3, MissedMajor: 2, MissedMinor: 1}) {
+		t.Errorf("expected 22.0, 31.0 and 31.1 to be missed, got %+v", *distance)
+	}
+
+	newest, err := GetNewestVersion(versions, WithScheme(Maven), ReleaseLineOf("31.0-android"))
+	if err != nil || newest != "31.1-android" {
+		t.Errorf("expected newest android release 31.1-android, got %q (%v)", newest, err)
+	}
+
+	dated := []deps.Version{
+		{Version: "31.0-android", PublishedAt: "2021-09-27T00:00:00Z"},
+		{Version: "31.0-jre", PublishedAt: "2021-09-27T00:00:00Z"},
+		{Version: "31.1-android", PublishedAt: "2022-02-28T00:00:00Z"},
+		{Version: "31.1-jre", PublishedAt: "2022-03-01T00:00:00Z"},
+	}
+	libyear, err := GetLibyear("31.0-android", dated, WithScheme(Maven))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if days := libyear.Hours() / 24; days != 154 {
+		t.Errorf("expected lag behind 31.1-android of 154 days, got %v", days)
+	}
+}
+
+func TestMavenRevisionDistance(t *testing.T) {
+	versions := []string{"1.2.3.4", "1.2.3.5", "1.2.3.5.1", "1.2.4.Final", "1.3"}
+
+	distance, err := GetVersionDistance("1.2.3.4", versions, WithScheme(Maven))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *distance != (VersionDistance{MissedReleases: 4, MissedMinor: 1, MissedPatch: 1, MissedRevision: 2}) {
+		t.Errorf("expected 2 revisions, 1 patch and 1 minor to be missed, got %+v", *distance)
+	}
+}
@@ -0,0 +1,244 @@
+package semver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// errInvalidPEP440Version is returned for versions that do not follow PEP 440
+var errInvalidPEP440Version = errors.New("invalid PEP 440 version")
+
+// pep440Pattern is the version pattern published with PEP 440, accepting all spellings
+// that normalize to a valid version, e.g. "1.0-RC.1" or "v2.0.post"
+var pep440Pattern = regexp.MustCompile(`(?i)^v?` +
+	`(?:([0-9]+)!)?` +
+	`([0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(alpha|a|beta|b|preview|pre|c|rc)[-_.]?([0-9]+)?)?` +
+	`(?:-([0-9]+)|[-_.]?(post|rev|r)[-_.]?([0-9]+)?)?` +
+	`(?:[-_.]?(dev)[-_.]?([0-9]+)?)?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// pep440PreReleases ranks the normalized pre-release phases
+var pep440PreReleases = map[string]int{"a": 0, "b": 1, "rc": 2}
+
+// pep440Scheme orders Python package versions as defined by PEP 440
+type pep440Scheme struct{}
+
+func (pep440Scheme) Name() string {
+	return "pep440"
+}
+
+func (pep440Scheme) Parse(rawVersion string) (Version, error) {
+	m := pep440Pattern.FindStringSubmatch(strings.TrimSpace(rawVersion))
+	if m == nil {
+		return nil, errInvalidPEP440Version
+	}
+
+	v := pep440Version{original: rawVersion, preNumber: -1, post: -1, dev: -1}
+	var err error
+	if m[1] != "" {
+		if v.epoch, err = strconv.ParseInt(m[1], 10, 64); err != nil {
+			return nil, errInvalidPEP440Version
+		}
+	}
+	for part := range strings.SplitSeq(m[2], ".") {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, errInvalidPEP440Version
+		}
+		v.release = append(v.release, n)
+	}
+
+	if m[3] != "" {
+		switch strings.ToLower(m[3]) {
+		case "a", "alpha":
+			v.pre = "a"
+		case "b", "beta":
+			v.pre = "b"
+		default:
+			v.pre = "rc"
+		}
+		v.preNumber = parseImplicitNumber(m[4])
+	}
+	switch {
+	case m[5] != "":
+		v.post = parseImplicitNumber(m[5])
+	case m[6] != "":
+		v.post = parseImplicitNumber(m[7])
+	}
+	if m[8] != "" {
+		v.dev = parseImplicitNumber(m[9])
+	}
+	if m[10] != "" {
+		v.local = strings.FieldsFunc(strings.ToLower(m[10]), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+
+	return v, nil
+}
+
+// parseImplicitNumber parses the number of a pre, post or dev segment, which is 0 if omitted
+func parseImplicitNumber(s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// pep440Version is a parsed PEP 440 version. Absent pre-release, post-release and development
+// numbers are -1.
+type pep440Version struct {
+	original  string
+	epoch     int64
+	release   []int64
+	pre       string
+	preNumber int64
+	post      int64
+	dev       int64
+	local     []string
+}
+
+// Compare orders versions by epoch, release, pre-release, post-release, development release
+// and local version label, with the special cases of PEP 440: a development release without
+// pre- or post-release sorts before all pre-releases of its release, and a release sorts before
+// its post-releases and local versions.
+func (v pep440Version) Compare(other Version) int {
+	o, ok := other.(pep440Version)
+	if !ok {
+		panic(fmt.Sprintf("cannot compare PEP 440 version %s with %T", v, other))
+	}
+
+	if c := cmp.Compare(v.epoch, o.epoch); c != 0 {
+		return c
+	}
+	if c := compareSegments(trimZeros(v.release), trimZeros(o.release)); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.preRank(), o.preRank()); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.preNumber, o.preNumber); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.post, o.post); c != 0 {
+		return c
+	}
+	// Development releases sort before the version they lead to
+	switch {
+	case v.dev < 0 && o.dev >= 0:
+		return 1
+	case v.dev >= 0 && o.dev < 0:
+		return -1
+	}
+	if c := cmp.Compare(v.dev, o.dev); c != 0 {
+		return c
+	}
+	return compareLocal(v.local, o.local)
+}
+
+// trimZeros removes trailing zero segments, since 1.0 and 1.0.0 are the same release
+func trimZeros(release []int64) []int64 {
+	end := len(release)
+	for end > 1 && release[end-1] == 0 {
+		end--
+	}
+	return release[:end]
+}
+
+// preRank ranks the pre-release phase; development releases of a final release precede all
+// pre-releases, and final releases follow them
+func (v pep440Version) preRank() int {
+	switch {
+	case v.pre != "":
+		return pep440PreReleases[v.pre]
+	case v.post < 0 && v.dev >= 0:
+		return -1
+	default:
+		return len(pep440PreReleases)
+	}
+}
+
+// compareLocal orders local version labels segment by segment. Numeric segments sort after
+// alphanumeric ones and versions without label sort first.
+func compareLocal(a, b []string) int {
+	for i := range min(len(a), len(b)) {
+		na, errA := strconv.ParseInt(a[i], 10, 64)
+		nb, errB := strconv.ParseInt(b[i], 10, 64)
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = cmp.Compare(na, nb)
+		case errA == nil:
+			c = 1
+		case errB == nil:
+			c = -1
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// Prerelease returns the pre-release and development parts, e.g. "rc1" or "a2.dev0".
+// Post-releases are releases.
+func (v pep440Version) Prerelease() string {
+	var b strings.Builder
+	if v.pre != "" {
+		b.WriteString(v.pre + strconv.FormatInt(v.preNumber, 10))
+	}
+	if v.dev >= 0 {
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString("dev" + strconv.FormatInt(v.dev, 10))
+	}
+	return b.String()
+}
+
+func (v pep440Version) Epoch() int64 {
+	return v.epoch
+}
+
+func (v pep440Version) Segments64() []int64 {
+	return v.release
+}
+
+func (v pep440Version) Original() string {
+	return v.original
+}
+
+// String returns the normalized form of the version
+func (v pep440Version) String() string {
+	var b strings.Builder
+	if v.epoch != 0 {
+		b.WriteString(strconv.FormatInt(v.epoch, 10) + "!")
+	}
+	for i, n := range v.release {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(strconv.FormatInt(n, 10))
+	}
+	if v.pre != "" {
+		b.WriteString(v.pre + strconv.FormatInt(v.preNumber, 10))
+	}
+	if v.post >= 0 {
+		b.WriteString(".post" + strconv.FormatInt(v.post, 10))
+	}
+	if v.dev >= 0 {
+		b.WriteString(".dev" + strconv.FormatInt(v.dev, 10))
+	}
+	if len(v.local) > 0 {
+		b.WriteString("+" + strings.Join(v.local, "."))
+	}
+	return b.String()
+}
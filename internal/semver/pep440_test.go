@@ -0,0 +1,117 @@
+package semver
+
+import (
+	"testing"
+)
+
+func TestPEP440Ordering(t *testing.T) {
+	// The ordering example of PEP 440, extended by epochs
+	ordered := []string{
+		"1.dev0",
+		"1.0.dev456",
+		"1.0a1",
+		"1.0a2.dev456",
+		"1.0a12.dev456",
+		"1.0a12",
+		"1.0b1.dev456",
+		"1.0b2",
+		"1.0b2.post345.dev456",
+		"1.0b2.post345",
+		"1.0rc1.dev456",
+		"1.0rc1",
+		"1.0",
+		"1.0+abc.5",
+		"1.0+abc.7",
+		"1.0+5",
+		"1.0.post456.dev34",
+		"1.0.post456",
+		"1.0.15",
+		"1.1.dev1",
+		"2.0rc1",
+		"2.0",
+		"1!0.9",
+		"1!2.0",
+	}
+
+	versions := make([]Version, len(ordered))
+	for i, raw := range ordered {
+		v, err := PEP440.Parse(raw)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", raw, err)
+		}
+		versions[i] = v
+	}
+	for i := range versions {
+		for j := range versions {
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			if got := versions[i].Compare(versions[j]); got != want {
+				t.Errorf("compare(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestPEP440Normalization(t *testing.T) {
+	for raw, normalized := range map[string]string{
+		"1.0.0":          "1.0.0",
+		"v1.0-RC.1":      "1.0rc1",
+		"1.0alpha2":      "1.0a2",
+		"1.0-preview_3":  "1.0rc3",
+		"1.0c1":          "1.0rc1",
+		"1.0-1":          "1.0.post1",
+		"1.0.rev":        "1.0.post0",
+		"1.0-dev":        "1.0.dev0",
+		"1!2.0b1.post2":  "1!2.0b1.post2",
+		"1.0+Ubuntu-1_a": "1.0+ubuntu.1.a",
+	} {
+		v, err := PEP440.Parse(raw)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", raw, err)
+			continue
+		}
+		if v.String() != normalized {
+			t.Errorf("%s: expected normalized %s, got %s", raw, normalized, v.String())
+		}
+		if want, _ := PEP440.Parse(normalized); v.Compare(want) != 0 {
+			t.Errorf("%s: expected to equal %s", raw, normalized)
+		}
+	}
+
+	for _, raw := range []string{"", "1.0-foo", "1.0.x", "latest", "1.0+"} {
+		if _, err := PEP440.Parse(raw); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+}
+
+func TestPEP440VersionDistance(t *testing.T) {
+	if SchemeFor("pypi") != PEP440 {
+		t.Fatal("expected PEP 440 ordering for pypi components")
+	}
+
+	versions := []string{"1.0", "1.0.post1", "1.1rc1", "1.1.dev0", "1.1", "2.0b1", "1!0.1"}
+
+	// Pre-releases and development releases are skipped; post-releases and epochs count
+	distance, err := GetVersionDistance("1.0", versions, WithScheme(PEP440))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if distance.MissedReleases != 3 {
+		t.Errorf("expected 1.0.post1, 1.1 and 1!0.1 to be missed, got %+v", *distance)
+	}
+	// The epoch bump from 1.1 to 1!0.1 is a new major version
+	if distance.MissedMajor != 1 || distance.MissedMinor != 1 {
+		t.Errorf("expected 1!0.1 to be a missed major and 1.1 a missed minor release, got %+v", *distance)
+	}
+
+	newest, err := GetNewestVersion(versions, WithScheme(PEP440))
+	if err != nil || newest != "1!0.1" {
+		t.Errorf("expected newest version 1!0.1, got %q (%v)", newest, err)
+	}
+}
@@ -0,0 +1,59 @@
+package semver
+
+import (
+	"regexp"
+	"sbom-technical-lag/internal/deps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// pseudoVersionPattern matches Go pseudo-versions in their three forms, vX.0.0-yyyymmddhhmmss-rev,
+// vX.Y.Z-pre.0.yyyymmddhhmmss-rev and vX.Y.(Z+1)-0.yyyymmddhhmmss-rev, as defined by the go command
+var pseudoVersionPattern = regexp.MustCompile(`^v?[0-9]+\.(?:0\.0-|[0-9]+\.[0-9]+-(?:[^+]*\.)?0\.)([0-9]{14})-[A-Za-z0-9]+(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// pseudoVersionTimeLayout is the UTC commit timestamp embedded in pseudo-versions
+const pseudoVersionTimeLayout = "20060102150405"
+
+// isPseudoVersion reports whether rawVersion is a Go pseudo-version referring to an untagged commit
+func isPseudoVersion(rawVersion string) bool {
+	_, ok := pseudoVersionTime(rawVersion)
+	return ok
+}
+
+// pseudoVersionTime returns the commit time embedded in a Go pseudo-version
+func pseudoVersionTime(rawVersion string) (time.Time, bool) {
+	match := pseudoVersionPattern.FindStringSubmatch(rawVersion)
+	if match == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(pseudoVersionTimeLayout, match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// withPseudoVersion adds a used pseudo-version to versions, dated by its commit time. Version
+// sources only list tagged releases, so the commit a module is pinned to is usually missing.
+func withPseudoVersion(versions []deps.Version, usedVersion string) []deps.Version {
+	published, ok := pseudoVersionTime(usedVersion)
+	if !ok {
+		return versions
+	}
+	date := published.Format(time.RFC3339)
+
+	i := slices.IndexFunc(versions, func(v deps.Version) bool {
+		return strings.TrimPrefix(v.Version, "v") == strings.TrimPrefix(usedVersion, "v")
+	})
+	if i >= 0 && versions[i].PublishedAt != "" {
+		return versions
+	}
+
+	versions = slices.Clone(versions)
+	if i >= 0 {
+		versions[i].PublishedAt = date
+		return versions
+	}
+	return append(versions, deps.Version{Version: usedVersion, PublishedAt: date})
+}
@@ -0,0 +1,71 @@
+package semver
+
+import (
+	"sbom-technical-lag/internal/deps"
+	"testing"
+	"time"
+)
+
+func TestPseudoVersionTime(t *testing.T) {
+	for version, want := range map[string]string{
+		"v0.0.0-20230101120000-abcdef123456":              "2023-01-01T12:00:00Z",
+		"v1.2.4-0.20230215093000-abcdef123456":            "2023-02-15T09:30:00Z",
+		"v1.3.0-rc.1.0.20230301000000-abcdef123456":       "2023-03-01T00:00:00Z",
+		"v2.0.0-20230101120000-abcdef123456+incompatible": "2023-01-01T12:00:00Z",
+	} {
+		published, ok := pseudoVersionTime(version)
+		if !ok || published.Format(time.RFC3339) != want {
+			t.Errorf("%s: expected %s, got %v %v", version, want, published, ok)
+		}
+	}
+
+	for _, version := range []string{"v1.2.3", "v1.2.3-rc.1", "v1.2.4-20230101120000-abcdef123456", "v1.0.0-0.2023-abc"} {
+		if isPseudoVersion(version) {
+			t.Errorf("%s: expected no pseudo-version", version)
+		}
+	}
+}
+
+func TestGetLibyearPseudoVersion(t *testing.T) {
+	versions := []deps.Version{
+		{Version: "v1.2.3", PublishedAt: "2023-01-01T00:00:00Z"},
+		{Version: "v1.2.4", PublishedAt: "2023-03-01T00:00:00Z"},
+		{Version: "v1.3.0", PublishedAt: "2023-06-01T00:00:00Z"},
+	}
+
+	// The commit after v1.2.3 is missing from the versions and dated by its timestamp
+	libyear, err := GetLibyear("v1.2.4-0.20230201000000-abcdef123456", versions)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if want := 120 * 24 * time.Hour; *libyear != want {
+		t.Errorf("expected libyear %v, got %v", want, *libyear)
+	}
+
+	distance, err := GetVersionDistance("v1.2.4-0.20230201000000-abcdef123456", []string{"v1.2.3", "v1.2.4", "v1.3.0"})
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *distance != (VersionDistance{MissedReleases: 2, MissedMinor: 1, MissedPatch: 1}) {
+		t.Errorf("expected the pseudo-version between v1.2.3 and v1.2.4, got %+v", *distance)
+	}
+
+	// A pseudo-version without an earlier tag precedes all releases
+	libyear, err = GetLibyear("v0.0.0-20221201000000-abcdef123456", versions)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if want := 182 * 24 * time.Hour; *libyear != want {
+		t.Errorf("expected libyear %v, got %v", want, *libyear)
+	}
+
+	// Pseudo-versions other than the used one are no releases
+	versions = append(versions, deps.Version{Version: "v1.3.1-0.20230701000000-abcdef123456", PublishedAt: "2023-07-01T00:00:00Z"})
+	libyear, err = GetLibyear("v1.3.0", versions)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *libyear != 0 {
+		t.Errorf("expected no lag behind a pseudo-version, got %v", *libyear)
+	}
+}
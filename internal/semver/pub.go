@@ -0,0 +1,141 @@
+package semver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// errInvalidPubVersion is returned for versions that are not major.minor.patch versions
+var errInvalidPubVersion = errors.New("invalid pub version")
+
+// pubVersionPattern is the complete version pattern of pub_semver
+var pubVersionPattern = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)` +
+	`(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?` +
+	`(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// pubScheme orders Dart and Flutter package versions like pub_semver. Unlike semantic
+// versioning, build numbers are ordered, e.g. 1.0.0 < 1.0.0+1 < 1.0.0+2.
+type pubScheme struct{}
+
+func (pubScheme) Name() string {
+	return "pub"
+}
+
+func (pubScheme) Parse(rawVersion string) (Version, error) {
+	m := pubVersionPattern.FindStringSubmatch(strings.TrimSpace(rawVersion))
+	if m == nil {
+		return nil, errInvalidPubVersion
+	}
+
+	v := pubVersion{original: rawVersion, pre: m[4], build: m[5]}
+	for i := range v.release {
+		n, err := strconv.ParseInt(m[i+1], 10, 64)
+		if err != nil {
+			return nil, errInvalidPubVersion
+		}
+		v.release[i] = n
+	}
+
+	return v, nil
+}
+
+// pubVersion is a parsed pub version
+type pubVersion struct {
+	original string
+	release  [3]int64
+	pre      string
+	build    string
+}
+
+// Compare orders versions by release, then pre-release with releases after their
+// pre-releases, then build with versions without build first
+func (v pubVersion) Compare(other Version) int {
+	o, ok := other.(pubVersion)
+	if !ok {
+		panic(fmt.Sprintf("cannot compare pub version %s with %T", v, other))
+	}
+
+	if c := compareSegments(v.release[:], o.release[:]); c != 0 {
+		return c
+	}
+	switch {
+	case v.pre == "" && o.pre != "":
+		return 1
+	case v.pre != "" && o.pre == "":
+		return -1
+	}
+	if c := comparePubIdentifiers(v.pre, o.pre); c != 0 {
+		return c
+	}
+	return comparePubIdentifiers(v.build, o.build)
+}
+
+// comparePubIdentifiers compares dot-separated identifiers; numbers sort before other
+// identifiers and a shorter list sorts first if it is a prefix of the other
+func comparePubIdentifiers(a, b string) int {
+	if a == b {
+		return 0
+	}
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	if a == "" {
+		partsA = nil
+	}
+	if b == "" {
+		partsB = nil
+	}
+
+	for i := range min(len(partsA), len(partsB)) {
+		na, errA := strconv.ParseInt(partsA[i], 10, 64)
+		nb, errB := strconv.ParseInt(partsB[i], 10, 64)
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = cmp.Compare(na, nb)
+		case errA == nil:
+			c = -1
+		case errB == nil:
+			c = 1
+		default:
+			c = strings.Compare(partsA[i], partsB[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(partsA), len(partsB))
+}
+
+// Prerelease returns the pre-release part; versions with only a build number are releases
+func (v pubVersion) Prerelease() string {
+	return v.pre
+}
+
+// Segments64 returns major, minor and patch, followed by a numeric build number as in
+// Flutter's 1.0.0+2, so that new builds of a release count as revisions
+func (v pubVersion) Segments64() []int64 {
+	segments := v.release[:]
+	build, _, _ := strings.Cut(v.build, ".")
+	if n, err := strconv.ParseInt(build, 10, 64); err == nil {
+		segments = append(segments, n)
+	}
+	return segments
+}
+
+func (v pubVersion) Original() string {
+	return v.original
+}
+
+func (v pubVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.release[0], v.release[1], v.release[2])
+	if v.pre != "" {
+		s += "-" + v.pre
+	}
+	if v.build != "" {
+		s += "+" + v.build
+	}
+	return s
+}
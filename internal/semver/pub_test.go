@@ -0,0 +1,41 @@
+package semver
+
+import (
+	"testing"
+)
+
+func TestPubOrdering(t *testing.T) {
+	// The ordering of pub_semver, including ordered build numbers
+	assertAscending(t, Pub, []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0-rc.1+build.1",
+		"1.0.0", "1.0.0+1", "1.0.0+2", "1.0.0+10", "1.0.0+build", "1.0.1", "1.1.0", "2.0.0-dev.1", "2.0.0",
+	})
+
+	for _, raw := range []string{"", "1.0", "1.0.0.0", "v1.0.0", "1.0.0+", "latest"} {
+		if _, err := Pub.Parse(raw); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+}
+
+func TestPubVersionDistance(t *testing.T) {
+	if SchemeFor("pub") != Pub {
+		t.Fatal("expected pub ordering for pub components")
+	}
+
+	versions := []string{"1.0.0+1", "1.0.0+2", "1.0.0+3", "1.1.0-dev.1", "1.1.0"}
+
+	// New builds of a release count as revisions, pre-releases are skipped
+	distance, err := GetVersionDistance("1.0.0+1", versions, WithScheme(Pub))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *distance != (VersionDistance{MissedReleases: 3, MissedMinor: 1, MissedRevision: 2}) {
+		t.Errorf("expected 2 builds and 1 minor release to be missed, got %+v", *distance)
+	}
+
+	newest, err := GetNewestVersion([]string{"1.0.0+2", "1.0.0+10", "1.0.0"}, WithScheme(Pub))
+	if err != nil || newest != "1.0.0+10" {
+		t.Errorf("expected newest version 1.0.0+10, got %q (%v)", newest, err)
+	}
+}
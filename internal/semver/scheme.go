@@ -0,0 +1,266 @@
+package semver
+
+import (
+	"fmt"
+	"log/slog"
+	"sbom-technical-lag/internal/deps"
+	"slices"
+
+	"github.com/hashicorp/go-version"
+	"github.com/package-url/packageurl-go"
+)
+
+// Version is a parsed version of one versioning scheme. Versions are only compared with
+// versions of the same scheme.
+type Version interface {
+	// Compare returns -1, 0 or 1 if the version is lower than, equal to or higher than other
+	Compare(other Version) int
+	// Prerelease returns the prerelease part, or "" for stable releases
+	Prerelease() string
+	// Segments64 returns the numeric release segments used to classify missed releases
+	Segments64() []int64
+	// Original returns the version as it was parsed
+	Original() string
+	// String returns the normalized version
+	String() string
+}
+
+// packagedVersion is implemented by versions of distro packages, which combine the upstream
+// version with a packaging revision such as a Debian revision or an RPM release
+type packagedVersion interface {
+	Version
+	// CompareUpstream compares like Compare, but ignores the packaging revision
+	CompareUpstream(other Version) int
+}
+
+// epochVersion is implemented by versions of schemes with an epoch, which takes precedence over
+// the release numbers, e.g. PEP 440's 1!0.1 or dpkg's 1:1.0-1
+type epochVersion interface {
+	// Epoch returns the epoch, 0 for versions without one
+	Epoch() int64
+}
+
+// epoch returns the epoch of v, or 0 if its scheme has no epochs
+func epoch(v Version) int64 {
+	if e, ok := v.(epochVersion); ok {
+		return e.Epoch()
+	}
+	return 0
+}
+
+// Scheme parses and orders the versions of an ecosystem
+type Scheme interface {
+	// Name identifies the scheme in logs
+	Name() string
+	// Parse parses a non-empty version string
+	Parse(rawVersion string) (Version, error)
+}
+
+var (
+	// SemVer orders versions with semantic versioning rules; it is used for most ecosystems
+	SemVer Scheme = semVerScheme{}
+	// Dpkg orders Debian package versions ([epoch:]upstream[-revision]) like dpkg
+	Dpkg Scheme = dpkgScheme{}
+	// RPM orders RPM package versions ([epoch:]version[-release]) like rpmvercmp
+	RPM Scheme = rpmScheme{}
+	// APK orders Alpine package versions (version[_suffix][-rN]) like apk-tools
+	APK Scheme = apkScheme{}
+	// PEP440 orders Python package versions ([N!]N(.N)*[{a|b|rc}N][.postN][.devN][+local])
+	PEP440 Scheme = pep440Scheme{}
+	// Maven orders Maven versions like Maven's ComparableVersion, e.g. 1.0-SNAPSHOT < 1.0 = 1.0.Final
+	Maven Scheme = mavenScheme{}
+	// Pub orders Dart and Flutter package versions like pub_semver, e.g. 1.0.0 < 1.0.0+1 < 1.0.0+2
+	Pub Scheme = pubScheme{}
+	// Composer orders PHP package versions like Composer, e.g. 1.0.0-RC1 < 1.0.0 < 1.0.0-p1
+	Composer Scheme = composerScheme{}
+)
+
+// SchemeFor returns the versioning scheme of a PURL type. Hex and Swift packages follow
+// semantic versioning, as do CocoaPods, whose Pod::Version orders semantic versions like it;
+// they use SemVer like all ecosystems without rules of their own.
+func SchemeFor(purlType string) Scheme {
+	switch purlType {
+	case packageurl.TypeDebian:
+		return Dpkg
+	case packageurl.TypeRPM:
+		return RPM
+	case packageurl.TypeApk:
+		return APK
+	case packageurl.TypePyPi:
+		return PEP440
+	case packageurl.TypeMaven:
+		return Maven
+	case packageurl.TypePub:
+		return Pub
+	case packageurl.TypeComposer:
+		return Composer
+	default:
+		return SemVer
+	}
+}
+
+// Option configures the version calculations
+type Option func(*options)
+
+type options struct {
+	scheme           Scheme
+	withdrawn        map[string]bool
+	includeWithdrawn bool
+	releaseLineOf    string
+}
+
+// WithScheme orders versions with the given scheme instead of semantic versioning
+func WithScheme(scheme Scheme) Option {
+	return func(o *options) {
+		if scheme != nil {
+			o.scheme = scheme
+		}
+	}
+}
+
+// WithVersionStatus provides the yanked, retracted and deprecated flags of versions given as
+// plain strings, so that withdrawn ones are skipped
+func WithVersionStatus(versions []deps.Version) Option {
+	return func(o *options) {
+		o.withdrawn = make(map[string]bool, len(versions))
+		for _, v := range versions {
+			if v.Withdrawn() {
+				o.withdrawn[v.Version] = true
+			}
+		}
+	}
+}
+
+// IncludeWithdrawn counts withdrawn versions like any other release, as newest version and
+// as missed release
+func IncludeWithdrawn() Option {
+	return func(o *options) {
+		o.includeWithdrawn = true
+	}
+}
+
+// ReleaseLineOf restricts GetNewestVersion to the release line of version, e.g. to the -jre
+// releases of Guava. GetVersionDistance and GetLibyear use the line of the used version.
+func ReleaseLineOf(version string) Option {
+	return func(o *options) {
+		o.releaseLineOf = version
+	}
+}
+
+// releaseLiner is implemented by versions of schemes with parallel release lines, such as
+// Guava's 31.1-jre and 31.1-android
+type releaseLiner interface {
+	// ReleaseLine returns the variant of the version, or "" if it belongs to no particular line
+	ReleaseLine() string
+}
+
+// releaseLine returns the release line of v, or "" if its scheme has no release lines
+func releaseLine(v Version) string {
+	if liner, ok := v.(releaseLiner); ok {
+		return liner.ReleaseLine()
+	}
+	return ""
+}
+
+// inReleaseLine returns a predicate for ascending versions that keeps those of the release line
+// of used. Versions without a line belong to all lines. If used belongs to no line either, the
+// variants of a release are kept once, so that they do not count as several releases.
+func inReleaseLine(used Version) func(Version) bool {
+	usedLine := releaseLine(used)
+	seen := make(map[string]bool)
+	return func(v Version) bool {
+		line := releaseLine(v)
+		if line != "" && usedLine != "" {
+			return line == usedLine
+		}
+		if usedLine != "" {
+			return true
+		}
+		key := fmt.Sprint(v.Segments64(), v.Prerelease())
+		if line != "" && seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+}
+
+// skipWithdrawn reports whether a version other than the used one is skipped as withdrawn
+func (o options) skipWithdrawn(v deps.Version, isUsed bool) bool {
+	return !o.includeWithdrawn && !isUsed && (v.Withdrawn() || o.withdrawn[v.Version])
+}
+
+// withdrawnFallback includes withdrawn versions if every version is withdrawn, as for packages
+// deprecated as a whole like npm's request. Skipping them would leave only the used version
+// and report the package as up to date.
+func (o options) withdrawnFallback(versions []deps.Version) options {
+	if o.includeWithdrawn || len(versions) == 0 {
+		return o
+	}
+	for _, v := range versions {
+		if !v.Withdrawn() && !o.withdrawn[v.Version] {
+			return o
+		}
+	}
+	slog.Default().Debug("All versions are withdrawn, counting them as releases", "versions", len(versions))
+	o.includeWithdrawn = true
+	return o
+}
+
+// releases removes withdrawn versions other than usedVersion from versions, unless all
+// versions are withdrawn
+func (o options) releases(usedVersion string, versions []string) []string {
+	if o.includeWithdrawn || len(o.withdrawn) == 0 {
+		return versions
+	}
+	status := make([]deps.Version, len(versions))
+	for i, v := range versions {
+		status[i] = deps.Version{Version: v}
+	}
+	if o.withdrawnFallback(status).includeWithdrawn {
+		return versions
+	}
+	return slices.DeleteFunc(slices.Clone(versions), func(v string) bool {
+		if o.skipWithdrawn(deps.Version{Version: v}, v == usedVersion) {
+			slog.Default().Debug("Skipping withdrawn version", "version", v)
+			return true
+		}
+		return false
+	})
+}
+
+func newOptions(opts []Option) options {
+	o := options{scheme: SemVer}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// semVerScheme parses versions with hashicorp/go-version
+type semVerScheme struct{}
+
+func (semVerScheme) Name() string {
+	return "semver"
+}
+
+func (semVerScheme) Parse(rawVersion string) (Version, error) {
+	v, err := version.NewVersion(rawVersion)
+	if err != nil {
+		return nil, err
+	}
+	return semVerVersion{v}, nil
+}
+
+// semVerVersion adapts a hashicorp version to the Version interface
+type semVerVersion struct {
+	*version.Version
+}
+
+func (v semVerVersion) Compare(other Version) int {
+	o, ok := other.(semVerVersion)
+	if !ok {
+		panic(fmt.Sprintf("cannot compare semver version %s with %T", v, other))
+	}
+	return v.Version.Compare(o.Version)
+}
@@ -8,8 +8,6 @@ import (
 	"slices"
 	"sort"
 	"time"
-
-	"github.com/hashicorp/go-version"
 )
 
 var (
@@ -21,6 +19,8 @@ var (
 	ErrVersionNotFound = errors.New("used version not found among valid versions")
 	// ErrNoVersionsProvided is returned when an empty versions slice is provided
 	ErrNoVersionsProvided = errors.New("no versions provided")
+	// ErrInvalidVersion is returned when a version does not follow the versioning scheme
+	ErrInvalidVersion = errors.New("invalid version")
 )
 
 // VersionDistance represents the distance metrics between versions
@@ -29,33 +29,39 @@ type VersionDistance struct {
 	MissedMajor    int64 `json:"missedMajor"`
 	MissedMinor    int64 `json:"missedMinor"`
 	MissedPatch    int64 `json:"missedPatch"`
+	// MissedRevision counts releases that only changed the fourth or a later segment, e.g.
+	// 4.2.1.3 to 4.2.1.4 as used by NuGet and some Maven artifacts
+	MissedRevision int64 `json:"missedRevision,omitempty"`
+	// MissedPackaging counts releases of distro packages that only changed the packaging
+	// revision, e.g. 2.36-9+deb12u3 to 2.36-9+deb12u4; they are not counted as patches
+	MissedPackaging int64 `json:"missedPackaging,omitempty"`
 }
 
-// parseSemver parses a version string into a semantic version with better error handling
-func parseSemver(rawVersion string) (*version.Version, error) {
+// parseVersion parses a version string with the given scheme with better error handling
+func parseVersion(scheme Scheme, rawVersion string) (Version, error) {
 	if rawVersion == "" {
 		return nil, ErrEmptyVersion
 	}
 
-	v, err := version.NewVersion(rawVersion)
+	v, err := scheme.Parse(rawVersion)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse version %q: %w", rawVersion, err)
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidVersion, rawVersion, err)
 	}
 
 	return v, nil
 }
 
-// parseAndFilterVersions converts string versions to semver and filters out invalid/prerelease versions
-func parseAndFilterVersions(versions []string) ([]*version.Version, error) {
+// parseAndFilterVersions parses string versions and filters out invalid/prerelease versions
+func parseAndFilterVersions(scheme Scheme, versions []string) ([]Version, error) {
 	if len(versions) == 0 {
 		return nil, ErrNoVersionsProvided
 	}
 
-	semVers := make([]*version.Version, 0, len(versions))
+	semVers := make([]Version, 0, len(versions))
 	var parseErrors int
 
 	for _, v := range versions {
-		semVer, err := parseSemver(v)
+		semVer, err := parseVersion(scheme, v)
 		if err != nil {
 			slog.Default().Debug("Skipping unparsable version", "version", v, "error", err)
 			parseErrors++
@@ -79,11 +85,10 @@ func parseAndFilterVersions(versions []string) ([]*version.Version, error) {
 	}
 
 	// Sort versions in ascending order for consistent processing
-	slices.SortFunc(semVers, func(a, b *version.Version) int {
-		return a.Compare(b)
-	})
+	slices.SortFunc(semVers, Version.Compare)
 
 	slog.Default().Debug("Parsed and filtered versions",
+		"scheme", scheme.Name(),
 		"total", len(versions),
 		"valid", len(semVers),
 		"parse_errors", parseErrors)
@@ -92,21 +97,21 @@ func parseAndFilterVersions(versions []string) ([]*version.Version, error) {
 }
 
 // findVersionIndex finds the index where usedVersion should be inserted in the sorted slice
-func findVersionIndex(sortedVersions []*version.Version, usedVersion *version.Version) int {
+func findVersionIndex(sortedVersions []Version, usedVersion Version) int {
 	return sort.Search(len(sortedVersions), func(i int) bool {
-		return sortedVersions[i].GreaterThanOrEqual(usedVersion)
+		return sortedVersions[i].Compare(usedVersion) >= 0
 	})
 }
 
 // insertVersionIfMissing inserts the used version into the sorted slice if it's not already present
-func insertVersionIfMissing(sortedVersions []*version.Version, usedVersion *version.Version, index int) ([]*version.Version, int) {
+func insertVersionIfMissing(sortedVersions []Version, usedVersion Version, index int) ([]Version, int) {
 	// If index is at the end, the used version is newer than all existing versions
 	if index == len(sortedVersions) {
 		return append(sortedVersions, usedVersion), index
 	}
 
 	// If the version at index is not equal to usedVersion, insert it
-	if !sortedVersions[index].Equal(usedVersion) {
+	if sortedVersions[index].Compare(usedVersion) != 0 {
 		return slices.Insert(sortedVersions, index, usedVersion), index
 	}
 
@@ -114,8 +119,61 @@ func insertVersionIfMissing(sortedVersions []*version.Version, usedVersion *vers
 	return sortedVersions, index
 }
 
+// releaseType is the kind of change between two consecutive releases
+type releaseType int
+
+const (
+	releaseMajor releaseType = iota
+	releaseMinor
+	releasePatch
+	releaseRevision
+	releasePackaging
+)
+
+// classifyRelease determines which part of the version changed from previous to current
+func classifyRelease(previous, current Version) releaseType {
+	// Distro packages rebuilt from the same upstream version only change the packaging
+	if packaged, ok := current.(packagedVersion); ok && packaged.CompareUpstream(previous) == 0 {
+		return releasePackaging
+	}
+	// A higher epoch resets the version numbers, e.g. 1.1 to 1!0.1, so it starts a new major
+	if epoch(current) > epoch(previous) {
+		return releaseMajor
+	}
+
+	length := max(len(current.Segments64()), len(previous.Segments64()))
+	currentSegments := normalizeSegments(current.Segments64(), length)
+	prevSegments := normalizeSegments(previous.Segments64(), length)
+
+	// Determine release type based on the first segment that changed
+	for i := range currentSegments {
+		if currentSegments[i] == prevSegments[i] {
+			continue
+		}
+		if currentSegments[i] < prevSegments[i] {
+			break
+		}
+		switch i {
+		case 0:
+			return releaseMajor
+		case 1:
+			return releaseMinor
+		case 2:
+			return releasePatch
+		default:
+			return releaseRevision
+		}
+	}
+
+	// This shouldn't happen with properly sorted versions, but handle gracefully
+	slog.Default().Debug("Unexpected version ordering",
+		"current", current.String(),
+		"previous", previous.String())
+	return releasePatch // Default to patch release
+}
+
 // calculateVersionDistance calculates the distance metrics between versions
-func calculateVersionDistance(sortedVersions []*version.Version, usedIndex int, usedVersion *version.Version) *VersionDistance {
+func calculateVersionDistance(sortedVersions []Version, usedIndex int, usedVersion Version) *VersionDistance {
 	missedReleases := len(sortedVersions) - 1 - usedIndex
 
 	if missedReleases <= 0 {
@@ -128,47 +186,37 @@ func calculateVersionDistance(sortedVersions []*version.Version, usedIndex int,
 	}
 
 	// Count missed releases by type
-	var missedMajor, missedMinor, missedPatch int64
-
-	usedSegments := normalizeSegments(usedVersion.Segments64())
+	var missedMajor, missedMinor, missedPatch, missedRevision, missedPackaging int64
 
 	// Count missed releases by examining each version after the used version
+	previous := usedVersion
 	for i := usedIndex + 1; i < len(sortedVersions); i++ {
-		currentSegments := normalizeSegments(sortedVersions[i].Segments64())
-
-		var prevSegments []int64
-		if i == usedIndex+1 {
-			prevSegments = usedSegments
-		} else {
-			prevSegments = normalizeSegments(sortedVersions[i-1].Segments64())
-		}
-
-		// Determine release type based on which segment changed
-		switch {
-		case currentSegments[0] > prevSegments[0]:
+		switch classifyRelease(previous, sortedVersions[i]) {
+		case releaseMajor:
 			missedMajor++
-		case currentSegments[1] > prevSegments[1]:
+		case releaseMinor:
 			missedMinor++
-		case currentSegments[2] > prevSegments[2]:
+		case releasePatch:
 			missedPatch++
-		default:
-			// This shouldn't happen with properly sorted versions, but handle gracefully
-			slog.Default().Debug("Unexpected version ordering",
-				"current", sortedVersions[i].String(),
-				"previous", sortedVersions[i-1].String())
-			missedPatch++ // Default to patch release
+		case releaseRevision:
+			missedRevision++
+		case releasePackaging:
+			missedPackaging++
 		}
+		previous = sortedVersions[i]
 	}
 
 	result := &VersionDistance{
-		MissedReleases: int64(missedReleases),
-		MissedMajor:    missedMajor,
-		MissedMinor:    missedMinor,
-		MissedPatch:    missedPatch,
+		MissedReleases:  int64(missedReleases),
+		MissedMajor:     missedMajor,
+		MissedMinor:     missedMinor,
+		MissedPatch:     missedPatch,
+		MissedRevision:  missedRevision,
+		MissedPackaging: missedPackaging,
 	}
 
 	// Verify consistency - the sum should equal total missed releases
-	sum := missedMajor + missedMinor + missedPatch
+	sum := missedMajor + missedMinor + missedPatch + missedRevision + missedPackaging
 	if sum != int64(missedReleases) {
 		slog.Default().Warn("Version distance calculation inconsistency",
 			"expected_total", missedReleases,
@@ -176,22 +224,21 @@ func calculateVersionDistance(sortedVersions []*version.Version, usedIndex int,
 			"major", missedMajor,
 			"minor", missedMinor,
 			"patch", missedPatch,
+			"revision", missedRevision,
+			"packaging", missedPackaging,
 			"used_version", usedVersion.String())
 
 		// Adjust patch count to maintain consistency
-		result.MissedPatch = max(int64(missedReleases)-missedMajor-missedMinor, 0)
+		result.MissedPatch = max(int64(missedReleases)-missedMajor-missedMinor-missedRevision-missedPackaging, 0)
 	}
 
 	return result
 }
 
-// normalizeSegments ensures version segments have at least major.minor.patch
-func normalizeSegments(segments []int64) []int64 {
-	if len(segments) >= 3 {
-		return segments[:3] // Take only first 3 segments
-	}
-
-	normalized := make([]int64, 3)
+// normalizeSegments pads version segments with zeros to length, and to at least major.minor.patch,
+// so that versions with a different number of segments can be compared segment by segment
+func normalizeSegments(segments []int64, length int) []int64 {
+	normalized := make([]int64, max(length, len(segments), 3))
 	copy(normalized, segments)
 	// Remaining elements are already 0 due to zero value
 
@@ -199,20 +246,23 @@ func normalizeSegments(segments []int64) []int64 {
 }
 
 // GetVersionDistance calculates how far behind a used version is compared to available versions
-func GetVersionDistance(usedVersion string, versions []string) (*VersionDistance, error) {
+func GetVersionDistance(usedVersion string, versions []string, opts ...Option) (*VersionDistance, error) {
 	if len(versions) == 0 {
 		return nil, ErrNoVersionsProvided
 	}
+	o := newOptions(opts)
 
-	usedSemver, err := parseSemver(usedVersion)
+	usedSemver, err := parseVersion(o.scheme, usedVersion)
 	if err != nil {
 		return nil, fmt.Errorf("invalid used version %q: %w", usedVersion, err)
 	}
 
-	sortedVersions, err := parseAndFilterVersions(versions)
+	sortedVersions, err := parseAndFilterVersions(o.scheme, o.releases(usedVersion, versions))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse available versions: %w", err)
 	}
+	keep := inReleaseLine(usedSemver)
+	sortedVersions = slices.DeleteFunc(sortedVersions, func(v Version) bool { return !keep(v) })
 
 	usedIndex := findVersionIndex(sortedVersions, usedSemver)
 	sortedVersions, usedIndex = insertVersionIfMissing(sortedVersions, usedSemver, usedIndex)
@@ -227,16 +277,41 @@ func GetVersionDistance(usedVersion string, versions []string) (*VersionDistance
 		"missed_releases", distance.MissedReleases,
 		"missed_major", distance.MissedMajor,
 		"missed_minor", distance.MissedMinor,
-		"missed_patch", distance.MissedPatch)
+		"missed_patch", distance.MissedPatch,
+		"missed_revision", distance.MissedRevision,
+		"missed_packaging", distance.MissedPackaging)
 
 	return distance, nil
 }
 
-// filterValidVersions filters out versions without publication dates or invalid semver
-func filterValidVersions(versions []deps.Version) ([]deps.Version, error) {
+// GetNewestVersion returns the highest stable version among versions as it was given.
+// Withdrawn versions are skipped.
+func GetNewestVersion(versions []string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	sortedVersions, err := parseAndFilterVersions(o.scheme, o.releases("", versions))
+	if err != nil {
+		return "", err
+	}
+	if o.releaseLineOf != "" {
+		if lineVersion, err := parseVersion(o.scheme, o.releaseLineOf); err == nil {
+			keep := inReleaseLine(lineVersion)
+			sortedVersions = slices.DeleteFunc(sortedVersions, func(v Version) bool { return !keep(v) })
+		}
+	}
+	if len(sortedVersions) == 0 {
+		return "", ErrNoValidVersions
+	}
+	return sortedVersions[len(sortedVersions)-1].Original(), nil
+}
+
+// filterValidVersions filters out versions without publication dates or invalid versions.
+// Withdrawn versions are dropped as well, unless they are the used version or all versions
+// are withdrawn.
+func filterValidVersions(o options, versions []deps.Version, usedSemver Version) ([]deps.Version, error) {
 	if len(versions) == 0 {
 		return nil, ErrNoVersionsProvided
 	}
+	o = o.withdrawnFallback(versions)
 
 	validVersions := make([]deps.Version, 0, len(versions))
 	var invalidCount int
@@ -259,19 +334,29 @@ func filterValidVersions(versions []deps.Version) ([]deps.Version, error) {
 			continue
 		}
 
-		sv, err := parseSemver(v.Version)
+		sv, err := parseVersion(o.scheme, v.Version)
 		if err != nil {
 			slog.Default().Debug("Skipping version with invalid semver", "version", v.Version, "error", err)
 			invalidCount++
 			continue
 		}
 
-		// Skip pre-release versions
-		if sv.Prerelease() != "" {
+		// Skip pre-release versions; a used Go pseudo-version is a commit between releases instead
+		if sv.Prerelease() != "" && (sv.Compare(usedSemver) != 0 || !isPseudoVersion(v.Version)) {
 			slog.Default().Debug("Skipping pre-release version", "version", v.Version, "prerelease", sv.Prerelease())
 			continue
 		}
 
+		// Skip withdrawn versions, they are neither an upgrade target nor a missed release
+		if o.skipWithdrawn(v, sv.Compare(usedSemver) == 0) {
+			slog.Default().Debug("Skipping withdrawn version",
+				"version", v.Version,
+				"yanked", v.Yanked,
+				"retracted", v.Retracted,
+				"deprecated", v.Deprecated)
+			continue
+		}
+
 		validVersions = append(validVersions, v)
 	}
 
@@ -290,11 +375,11 @@ func filterValidVersions(versions []deps.Version) ([]deps.Version, error) {
 	return validVersions, nil
 }
 
-// sortVersionsBySemanticVersion sorts versions by their semantic version
-func sortVersionsBySemanticVersion(versions []deps.Version) error {
+// sortVersionsBySemanticVersion sorts versions by their version according to scheme
+func sortVersionsBySemanticVersion(scheme Scheme, versions []deps.Version) error {
 	slices.SortFunc(versions, func(a, b deps.Version) int {
-		semverA, errA := parseSemver(a.Version)
-		semverB, errB := parseSemver(b.Version)
+		semverA, errA := parseVersion(scheme, a.Version)
+		semverB, errB := parseVersion(scheme, b.Version)
 
 		// This shouldn't happen since we already filtered, but be defensive
 		if errA != nil && errB != nil {
@@ -314,13 +399,13 @@ func sortVersionsBySemanticVersion(versions []deps.Version) error {
 }
 
 // findUsedVersionIndex finds the index of the used version in the sorted slice
-func findUsedVersionIndex(sortedVersions []deps.Version, usedSemver *version.Version) (int, error) {
+func findUsedVersionIndex(scheme Scheme, sortedVersions []deps.Version, usedSemver Version) (int, error) {
 	idx := slices.IndexFunc(sortedVersions, func(v deps.Version) bool {
-		sv, err := parseSemver(v.Version)
+		sv, err := parseVersion(scheme, v.Version)
 		if err != nil {
 			return false
 		}
-		return sv.Equal(usedSemver)
+		return sv.Compare(usedSemver) == 0
 	})
 
 	if idx == -1 {
@@ -330,27 +415,35 @@ func findUsedVersionIndex(sortedVersions []deps.Version, usedSemver *version.Ver
 	return idx, nil
 }
 
-// GetLibyear calculates the "libyear" metric - time difference between used version and newest version
-func GetLibyear(usedVersion string, versions []deps.Version) (*time.Duration, error) {
+// GetLibyear calculates the "libyear" metric - time difference between used version and newest version.
+// A Go pseudo-version in use is dated by its commit time, even if it is not among versions.
+func GetLibyear(usedVersion string, versions []deps.Version, opts ...Option) (*time.Duration, error) {
 	if len(versions) == 0 {
 		return nil, ErrNoVersionsProvided
 	}
+	o := newOptions(opts)
 
-	usedSemver, err := parseSemver(usedVersion)
+	usedSemver, err := parseVersion(o.scheme, usedVersion)
 	if err != nil {
 		return nil, fmt.Errorf("invalid used version %q: %w", usedVersion, err)
 	}
+	versions = withPseudoVersion(versions, usedVersion)
 
-	validVersions, err := filterValidVersions(versions)
+	validVersions, err := filterValidVersions(o, versions, usedSemver)
 	if err != nil {
 		return nil, fmt.Errorf("failed to filter versions: %w", err)
 	}
 
-	if err := sortVersionsBySemanticVersion(validVersions); err != nil {
+	if err := sortVersionsBySemanticVersion(o.scheme, validVersions); err != nil {
 		return nil, fmt.Errorf("failed to sort versions: %w", err)
 	}
+	keep := inReleaseLine(usedSemver)
+	validVersions = slices.DeleteFunc(validVersions, func(v deps.Version) bool {
+		sv, err := parseVersion(o.scheme, v.Version)
+		return err == nil && !keep(sv)
+	})
 
-	usedIdx, err := findUsedVersionIndex(validVersions, usedSemver)
+	usedIdx, err := findUsedVersionIndex(o.scheme, validVersions, usedSemver)
 	if err != nil {
 		return nil, fmt.Errorf("used version %q not found: %w", usedVersion, err)
 	}
@@ -1,6 +1,7 @@
 package semver
 
 import (
+	"errors"
 	"sbom-technical-lag/internal/deps"
 	"testing"
 	"time"
@@ -254,6 +255,39 @@ func TestVersionDistanceEdgeCases(t *testing.T) {
 		}
 	})
 
+	// Test case with four-part versions, as used by NuGet
+	t.Run("FourSegmentReleases", func(t *testing.T) {
+		usedVersion := "4.2.1.3"
+		versions := []string{"4.2.1.3", "4.2.1.4", "4.2.1.5", "4.2.2", "4.2.2.1", "4.3.0.0", "5.0"}
+
+		d, err := GetVersionDistance(usedVersion, versions)
+		if err != nil {
+			t.Fatalf("no error expected")
+		}
+
+		if d.MissedReleases != 6 {
+			t.Errorf("Expected 6 missed releases, got %d", d.MissedReleases)
+		}
+		if d.MissedMajor != 1 {
+			t.Errorf("Expected 1 missed major, got %d", d.MissedMajor)
+		}
+		if d.MissedMinor != 1 {
+			t.Errorf("Expected 1 missed minor, got %d", d.MissedMinor)
+		}
+		if d.MissedPatch != 1 {
+			t.Errorf("Expected 1 missed patch, got %d", d.MissedPatch)
+		}
+		if d.MissedRevision != 3 {
+			t.Errorf("Expected 3 missed revision, got %d", d.MissedRevision)
+		}
+
+		// Verify sum equals total
+		total := d.MissedMajor + d.MissedMinor + d.MissedPatch + d.MissedRevision
+		if total != d.MissedReleases {
+			t.Errorf("Sum (%d) != total (%d)", total, d.MissedReleases)
+		}
+	})
+
 	// Test case where used version is the latest
 	t.Run("LatestVersion", func(t *testing.T) {
 		usedVersion := "2.0.0"
@@ -378,3 +412,147 @@ func TestVersionDistanceNoNegativeValues(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLibyearSkipsYankedVersions(t *testing.T) {
+	versions := []deps.Version{
+		{Version: "1.0.0", PublishedAt: "2021-01-01T00:00:00Z"},
+		{Version: "1.1.0", PublishedAt: "2021-03-01T00:00:00Z"},
+		{Version: "1.2.0", PublishedAt: "2021-06-01T00:00:00Z", Yanked: true},
+	}
+
+	libyear, err := GetLibyear("1.0.0", versions)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	// The newest version must be 1.1.0 since 1.2.0 was yanked
+	usedTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	newestTime, _ := time.Parse(time.RFC3339, "2021-03-01T00:00:00Z")
+	if *libyear != newestTime.Sub(usedTime) {
+		t.Fatalf("unexpected libyear duration. Expected %v, got %v", newestTime.Sub(usedTime), *libyear)
+	}
+}
+
+func TestGetLibyearYankedUsedVersion(t *testing.T) {
+	versions := []deps.Version{
+		{Version: "1.0.0", PublishedAt: "2021-01-01T00:00:00Z", Yanked: true},
+		{Version: "1.1.0", PublishedAt: "2021-03-01T00:00:00Z"},
+	}
+
+	// A yanked version that is in use must still be found
+	libyear, err := GetLibyear("1.0.0", versions)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *libyear <= 0 {
+		t.Fatalf("expected positive libyear, got %v", *libyear)
+	}
+}
+
+func TestGetNewestVersion(t *testing.T) {
+	newest, err := GetNewestVersion([]string{"v1.2.0", "v1.10.0", "v2.0.0-rc.1", "v1.9.3", "invalid"})
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if newest != "v1.10.0" {
+		t.Errorf("expected newest stable version v1.10.0, got %q", newest)
+	}
+
+	if _, err := GetNewestVersion([]string{"invalid"}); !errors.Is(err, ErrNoValidVersions) {
+		t.Errorf("expected ErrNoValidVersions, got: %v", err)
+	}
+}
+
+func TestWithdrawnVersions(t *testing.T) {
+	status := []deps.Version{
+		{Version: "1.0.0"},
+		{Version: "1.1.0", Deprecated: true},
+		{Version: "1.2.0", Retracted: true},
+		{Version: "1.2.1"},
+		{Version: "2.0.0", Deprecated: true},
+	}
+	versions := []string{"1.0.0", "1.1.0", "1.2.0", "1.2.1", "2.0.0"}
+
+	distance, err := GetVersionDistance("1.0.0", versions, WithVersionStatus(status))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *distance != (VersionDistance{MissedReleases: 1, MissedMinor: 1}) {
+		t.Errorf("expected withdrawn releases to be skipped, got %+v", *distance)
+	}
+	if newest, _ := GetNewestVersion(versions, WithVersionStatus(status)); newest != "1.2.1" {
+		t.Errorf("expected newest non-withdrawn version 1.2.1, got %q", newest)
+	}
+
+	// The used version is kept even if it was withdrawn
+	distance, err = GetVersionDistance("1.1.0", versions, WithVersionStatus(status))
+	if err != nil {
+		t.Fatalf("no error expected for a withdrawn used version, got: %v", err)
+	}
+	if distance.MissedReleases != 1 {
+		t.Errorf("expected 1 missed release, got %+v", *distance)
+	}
+
+	distance, err = GetVersionDistance("1.0.0", versions, WithVersionStatus(status), IncludeWithdrawn())
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if distance.MissedReleases != 4 || distance.MissedMajor != 1 {
+		t.Errorf("expected all releases to count with IncludeWithdrawn, got %+v", *distance)
+	}
+	if newest, _ := GetNewestVersion(versions, WithVersionStatus(status), IncludeWithdrawn()); newest != "2.0.0" {
+		t.Errorf("expected newest version 2.0.0 with IncludeWithdrawn, got %q", newest)
+	}
+}
+
+func TestAllVersionsWithdrawn(t *testing.T) {
+	// Deprecating a package as a whole, like npm's request, deprecates every version
+	versions := []deps.Version{
+		{Version: "2.87.0", PublishedAt: "2018-05-21T00:00:00Z", Deprecated: true},
+		{Version: "2.88.0", PublishedAt: "2018-08-10T00:00:00Z", Deprecated: true},
+		{Version: "2.88.2", PublishedAt: "2020-02-11T00:00:00Z", Deprecated: true},
+	}
+	rawVersions := []string{"2.87.0", "2.88.0", "2.88.2"}
+
+	distance, err := GetVersionDistance("2.87.0", rawVersions, WithVersionStatus(versions))
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *distance != (VersionDistance{MissedReleases: 2, MissedMinor: 1, MissedPatch: 1}) {
+		t.Errorf("expected the deprecated history to count, got %+v", *distance)
+	}
+	if newest, _ := GetNewestVersion(rawVersions, WithVersionStatus(versions)); newest != "2.88.2" {
+		t.Errorf("expected newest deprecated version 2.88.2, got %q", newest)
+	}
+
+	libyear, err := GetLibyear("2.87.0", versions)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *libyear != 631*24*time.Hour {
+		t.Errorf("expected lag behind the newest deprecated version, got %v", *libyear)
+	}
+}
+
+func TestGetLibyearIncludeWithdrawn(t *testing.T) {
+	versions := []deps.Version{
+		{Version: "1.0.0", PublishedAt: "2021-01-01T00:00:00Z"},
+		{Version: "1.1.0", PublishedAt: "2021-03-01T00:00:00Z", Deprecated: true},
+	}
+
+	libyear, err := GetLibyear("1.0.0", versions)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *libyear != 0 {
+		t.Errorf("expected no lag behind a deprecated version, got %v", *libyear)
+	}
+
+	libyear, err = GetLibyear("1.0.0", versions, IncludeWithdrawn())
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if *libyear != 59*24*time.Hour {
+		t.Errorf("expected lag behind the deprecated version, got %v", *libyear)
+	}
+}
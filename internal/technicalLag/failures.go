@@ -0,0 +1,58 @@
+package technicalLag
+
+import (
+	"context"
+	"errors"
+	"sbom-technical-lag/internal/deps"
+	"sbom-technical-lag/internal/semver"
+)
+
+// ErrMissingVersion is returned for components without a version
+var ErrMissingVersion = errors.New("component has no version")
+
+// FailureCategory classifies why the lag of a component could not be calculated
+type FailureCategory string
+
+const (
+	FailureNotFound             FailureCategory = "notFound"
+	FailureUnknownRepository    FailureCategory = "unknownRepository"
+	FailureDirectFetch          FailureCategory = "directFetch"
+	FailureRateLimited          FailureCategory = "rateLimited"
+	FailureUnsupportedEcosystem FailureCategory = "unsupportedEcosystem"
+	FailureInvalidPURL          FailureCategory = "invalidPurl"
+	FailureNoValidVersions      FailureCategory = "noValidVersions"
+	FailureUsedVersionUnknown   FailureCategory = "usedVersionUnknown"
+	FailureMissingVersion       FailureCategory = "missingVersion"
+	FailureCancelled            FailureCategory = "cancelled"
+	FailureOther                FailureCategory = "other"
+)
+
+// ClassifyFailure returns the category of an error returned for a component. Errors of
+// chained sources can match several categories; the most specific one wins, e.g. a package
+// that one source does not support and another does not know counts as not found.
+func ClassifyFailure(err error) FailureCategory {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return FailureCancelled
+	case errors.Is(err, deps.ErrRateLimited):
+		return FailureRateLimited
+	case errors.Is(err, deps.ErrInvalidPURL):
+		return FailureInvalidPURL
+	case errors.Is(err, ErrMissingVersion), errors.Is(err, semver.ErrEmptyVersion):
+		return FailureMissingVersion
+	case errors.Is(err, semver.ErrVersionNotFound), errors.Is(err, semver.ErrInvalidVersion):
+		return FailureUsedVersionUnknown
+	case errors.Is(err, semver.ErrNoValidVersions), errors.Is(err, semver.ErrNoVersionsProvided):
+		return FailureNoValidVersions
+	case errors.Is(err, deps.ErrUnknownRepository):
+		return FailureUnknownRepository
+	case errors.Is(err, deps.ErrPackageNotFound), errors.Is(err, deps.ErrNotInSnapshot):
+		return FailureNotFound
+	case errors.Is(err, deps.ErrDirectModuleFetch):
+		return FailureDirectFetch
+	case errors.Is(err, deps.ErrUnsupportedPackageType):
+		return FailureUnsupportedEcosystem
+	default:
+		return FailureOther
+	}
+}
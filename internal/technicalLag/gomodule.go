@@ -0,0 +1,153 @@
+package technicalLag
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sbom-technical-lag/internal/deps"
+	"sbom-technical-lag/internal/semver"
+	"strconv"
+	"strings"
+)
+
+// maxGoMajorProbes bounds the successor module paths looked up per Go module
+const maxGoMajorProbes = 10
+
+// goIncompatibleSuffix marks releases of a major version >= 2 in a module path without the
+// major version suffix, tagged before the repository adopted modules
+const goIncompatibleSuffix = "+incompatible"
+
+var (
+	// goMajorPathSuffix matches the major version suffix of a module path, e.g. /v2
+	goMajorPathSuffix = regexp.MustCompile(`/v([2-9]|[1-9][0-9]+)$`)
+	// gopkgInMajorSuffix matches the major version of a gopkg.in path, e.g. gopkg.in/yaml.v3
+	gopkgInMajorSuffix = regexp.MustCompile(`^(.*gopkg\.in/.*)\.v([0-9]+)$`)
+)
+
+// goSuccessor is the release history of a module path with a higher major version
+type goSuccessor struct {
+	purl string
+	resp *deps.APIResponse
+}
+
+// goMajorSuccessors looks up the module paths that succeed the module of rawPURL with higher
+// major versions, e.g. example.com/lib/v2 and example.com/lib/v3 for example.com/lib. Go treats
+// them as different modules, so their releases are not listed with the module itself. Paths
+// are probed in order until one is not found; the major version of +incompatible releases may
+// continue in its own path, so a missing path for it does not end the search. Paths that
+// were not found are remembered and not probed again.
+func (calc *Calculator) goMajorSuccessors(ctx context.Context, rawPURL string, listed []deps.VersionsAPIResponse) []goSuccessor {
+	// The successor PURLs keep qualifiers and subpath but refer to no version
+	modulePURL, rest := rawPURL, ""
+	if i := strings.IndexAny(rawPURL, "@?#"); i >= 0 {
+		modulePURL = rawPURL[:i]
+	}
+	if i := strings.IndexAny(rawPURL, "?#"); i >= 0 {
+		rest = rawPURL[i:]
+	}
+
+	successorPURL, pathMajor, ok := goMajorPath(modulePURL)
+	if !ok {
+		return nil
+	}
+
+	// Majors released as +incompatible in this path may continue in a path of their own
+	highestListed := 0
+	for _, v := range listed {
+		if !strings.HasSuffix(v.Version.Version, goIncompatibleSuffix) {
+			continue
+		}
+		if parsed, err := semver.SemVer.Parse(v.Version.Version); err == nil {
+			highestListed = max(highestListed, int(parsed.Segments64()[0]))
+		}
+	}
+
+	var successors []goSuccessor
+	major := max(pathMajor+1, highestListed)
+	for range maxGoMajorProbes {
+		purl := successorPURL(major) + rest
+		if resp, found := calc.probeModule(ctx, purl); found {
+			calc.logger.Debug("Found successor module path", "purl", purl, "versions", len(resp.Versions))
+			successors = append(successors, goSuccessor{purl: purl, resp: resp})
+		} else if major > highestListed {
+			break
+		}
+		major++
+	}
+
+	return successors
+}
+
+// probeModule looks up the versions of a successor module path. Paths without releases are
+// remembered, other failures such as rate limits are retried by the next probe.
+func (calc *Calculator) probeModule(ctx context.Context, purl string) (*deps.APIResponse, bool) {
+	calc.mu.Lock()
+	missing := calc.missingModules[purl]
+	calc.mu.Unlock()
+	if missing {
+		calc.logger.Debug("Skipping successor module path known to be missing", "purl", purl)
+		return nil, false
+	}
+
+	resp, err := calc.source.GetVersions(ctx, purl)
+	if err == nil && len(resp.Versions) > 0 {
+		return resp, true
+	}
+	calc.logger.Debug("No successor module path", "purl", purl, "error", err)
+	if err == nil || errors.Is(err, deps.ErrPackageNotFound) || errors.Is(err, deps.ErrNotInSnapshot) {
+		calc.mu.Lock()
+		if calc.missingModules == nil {
+			calc.missingModules = make(map[string]bool)
+		}
+		calc.missingModules[purl] = true
+		calc.mu.Unlock()
+	}
+	return nil, false
+}
+
+// goMajorPath returns a function building the PURL of the module path with another major
+// version from the versionless PURL of a module, and the major version of the module path
+func goMajorPath(modulePURL string) (func(major int) string, int, bool) {
+	if match := gopkgInMajorSuffix.FindStringSubmatch(modulePURL); match != nil {
+		major, err := strconv.Atoi(match[2])
+		if err != nil {
+			return nil, 0, false
+		}
+		return func(major int) string { return match[1] + ".v" + strconv.Itoa(major) }, major, true
+	}
+
+	base, pathMajor := modulePURL, 1
+	if match := goMajorPathSuffix.FindStringSubmatchIndex(modulePURL); match != nil {
+		major, err := strconv.Atoi(modulePURL[match[2]:match[3]])
+		if err != nil {
+			return nil, 0, false
+		}
+		base, pathMajor = modulePURL[:match[0]], major
+	}
+	return func(major int) string { return base + "/v" + strconv.Itoa(major) }, pathMajor, true
+}
+
+// appendSuccessorVersions adds the releases of successor module paths to versions. Tags are
+// unique per repository, so a release listed as +incompatible is not expected in a successor
+// path; should a source list it twice, it is only counted once.
+func appendSuccessorVersions(versions []deps.Version, successors []goSuccessor) []deps.Version {
+	known := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		known[trimIncompatible(v.Version)] = true
+	}
+	for _, successor := range successors {
+		for _, v := range convertVersions(successor.resp.Versions) {
+			if !known[trimIncompatible(v.Version)] {
+				known[trimIncompatible(v.Version)] = true
+				versions = append(versions, v)
+			}
+		}
+	}
+	return versions
+}
+
+// trimIncompatible removes the +incompatible marker, so that versions of the module path and
+// its successors can be matched
+func trimIncompatible(version string) string {
+	return strings.TrimSuffix(version, goIncompatibleSuffix)
+}
@@ -0,0 +1,157 @@
+package technicalLag
+
+import (
+	"context"
+	"sbom-technical-lag/internal/deps"
+	"sbom-technical-lag/internal/semver"
+	"sync"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+)
+
+func TestGoMajorPath(t *testing.T) {
+	tests := []struct {
+		purl      string
+		successor string
+		pathMajor int
+	}{
+		{"pkg:golang/github.com/acme/lib", "pkg:golang/github.com/acme/lib/v2", 1},
+		{"pkg:golang/github.com/acme/lib/v2", "pkg:golang/github.com/acme/lib/v3", 2},
+		{"pkg:golang/github.com/acme/v2tools", "pkg:golang/github.com/acme/v2tools/v2", 1},
+		{"pkg:golang/gopkg.in/yaml.v2", "pkg:golang/gopkg.in/yaml.v3", 2},
+	}
+
+	for _, tt := range tests {
+		successorPURL, pathMajor, ok := goMajorPath(tt.purl)
+		if !ok {
+			t.Errorf("%s: expected a module path", tt.purl)
+			continue
+		}
+		if got := successorPURL(pathMajor + 1); got != tt.successor || pathMajor != tt.pathMajor {
+			t.Errorf("%s: expected successor %s of major %d, got %s of major %d", tt.purl, tt.successor, tt.pathMajor, got, pathMajor)
+		}
+	}
+}
+
+// goModuleSnapshot builds a snapshot with the given release dates of each module PURL
+func goModuleSnapshot(t *testing.T, modules map[string]map[string]string) *deps.Snapshot {
+	t.Helper()
+	snapshot := deps.NewSnapshot()
+	for purl, releases := range modules {
+		resp := &deps.APIResponse{}
+		for version, date := range releases {
+			resp.Versions = append(resp.Versions, deps.VersionsAPIResponse{Version: deps.Version{Version: version, PublishedAt: date}})
+		}
+		if err := snapshot.Add(purl, resp); err != nil {
+			t.Fatalf("failed to build snapshot: %v", err)
+		}
+	}
+	return snapshot
+}
+
+func TestCalculateGoMajorSuccessors(t *testing.T) {
+	snapshot := goModuleSnapshot(t, map[string]map[string]string{
+		"pkg:golang/github.com/acme/lib": {
+			"v1.8.0": "2022-01-01T00:00:00Z",
+			"v1.9.0": "2022-06-01T00:00:00Z",
+		},
+		"pkg:golang/github.com/acme/lib/v2": {
+			"v2.0.0": "2023-01-01T00:00:00Z",
+			"v2.1.0": "2023-06-01T00:00:00Z",
+		},
+		"pkg:golang/github.com/acme/lib/v3": {
+			"v3.0.0": "2024-01-01T00:00:00Z",
+		},
+	})
+	calc := NewCalculator(nil, 1, WithSnapshot(snapshot))
+
+	component := cdx.Component{Name: "lib", Version: "v1.9.0", PackageURL: "pkg:golang/github.com/acme/lib@v1.9.0"}
+	lag, err := calc.calculateComponentLag(context.Background(), component)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag.VersionDistance != (semver.VersionDistance{MissedReleases: 3, MissedMajor: 2, MissedMinor: 1}) || lag.Libdays != 579 {
+		t.Errorf("expected the /v2 and /v3 releases to be missed, got %+v", lag)
+	}
+
+	// A module on the newest major path only misses its own successors
+	component = cdx.Component{Name: "lib", Version: "v2.0.0", PackageURL: "pkg:golang/github.com/acme/lib/v2@v2.0.0"}
+	lag, err = calc.calculateComponentLag(context.Background(), component)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag.VersionDistance != (semver.VersionDistance{MissedReleases: 2, MissedMajor: 1, MissedMinor: 1}) {
+		t.Errorf("expected v2.1.0 and v3.0.0 to be missed, got %+v", lag)
+	}
+}
+
+func TestCalculateGoIncompatible(t *testing.T) {
+	snapshot := goModuleSnapshot(t, map[string]map[string]string{
+		"pkg:golang/github.com/acme/legacy": {
+			"v1.0.0":              "2018-01-01T00:00:00Z",
+			"v2.0.0+incompatible": "2018-06-01T00:00:00Z",
+			"v3.0.0+incompatible": "2019-01-01T00:00:00Z",
+		},
+		// The repository adopted modules with v4, there is no /v3 path
+		"pkg:golang/github.com/acme/legacy/v4": {
+			"v4.0.0": "2020-01-01T00:00:00Z",
+		},
+	})
+	calc := NewCalculator(nil, 1, WithSnapshot(snapshot))
+
+	component := cdx.Component{Name: "legacy", Version: "v2.0.0+incompatible", PackageURL: "pkg:golang/github.com/acme/legacy@v2.0.0%2Bincompatible"}
+	lag, err := calc.calculateComponentLag(context.Background(), component)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag.VersionDistance != (semver.VersionDistance{MissedReleases: 2, MissedMajor: 2}) {
+		t.Errorf("expected v3.0.0+incompatible and /v4 to be missed, got %+v", lag)
+	}
+
+	exported, err := NewCalculator(nil, 1, WithVersionSource(snapshot)).ExportSnapshot(context.Background(), &cdx.BOM{Components: &[]cdx.Component{component}})
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if exported.Len() != 2 {
+		t.Errorf("expected the module and its /v4 successor in the snapshot, got %d packages", exported.Len())
+	}
+}
+
+// countingSource counts the lookups of each PURL
+type countingSource struct {
+	deps.VersionSource
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingSource) GetVersions(ctx context.Context, rawPURL string) (*deps.APIResponse, error) {
+	c.mu.Lock()
+	c.calls[rawPURL]++
+	c.mu.Unlock()
+	return c.VersionSource.GetVersions(ctx, rawPURL)
+}
+
+func TestGoMajorSuccessorsProbeMissingPathsOnce(t *testing.T) {
+	source := &countingSource{
+		VersionSource: fakeSource{byPURL: map[string]*deps.APIResponse{
+			"pkg:golang/github.com/acme/lib": {Versions: []deps.VersionsAPIResponse{
+				{Version: deps.Version{Version: "v1.0.0", PublishedAt: "2022-01-01T00:00:00Z"}},
+				{Version: deps.Version{Version: "v1.1.0", PublishedAt: "2022-06-01T00:00:00Z"}},
+			}},
+		}},
+		calls: make(map[string]int),
+	}
+	calc := NewCalculator(nil, 1, WithVersionSource(source))
+
+	for _, version := range []string{"v1.0.0", "v1.1.0"} {
+		component := cdx.Component{Name: "lib", Version: version, PackageURL: "pkg:golang/github.com/acme/lib@" + version}
+		if _, err := calc.calculateComponentLag(context.Background(), component); err != nil {
+			t.Fatalf("no error expected, got: %v", err)
+		}
+	}
+	if n := source.calls["pkg:golang/github.com/acme/lib/v2"]; n != 1 {
+		t.Errorf("expected the missing /v2 path to be probed once, got %d lookups", n)
+	}
+}
@@ -4,30 +4,123 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"sbom-technical-lag/internal/deps"
 	"sbom-technical-lag/internal/sbom"
 	"sbom-technical-lag/internal/semver"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
 )
 
 // TechnicalLag represents the technical lag metrics for a component
 type TechnicalLag struct {
-	Libdays         float64                `json:"libdays"`
+	Libdays float64 `json:"libdays"`
+	// LibdaysUnknown marks components whose source has no publication dates, e.g. Debian
+	// packages; their Libdays of 0 is left out of libyear totals and averages
+	LibdaysUnknown  bool                   `json:"libdaysUnknown,omitempty"`
 	VersionDistance semver.VersionDistance `json:"versionDistance"`
+	// UsedVersion and NewestVersion hold deprecation, license and advisory details if available
+	UsedVersion   *deps.VersionInfo `json:"usedVersion,omitempty"`
+	NewestVersion *deps.VersionInfo `json:"newestVersion,omitempty"`
+
+	// newestVersion is set if details of the used and newest versions should be looked up
+	newestVersion string
 }
 
 // Calculator handles technical lag calculations
 type Calculator struct {
-	depsClient *deps.Client
-	logger     *slog.Logger
-	maxWorkers int
+	source          deps.VersionSource
+	registries      []deps.VersionSource
+	depsClient      *deps.Client
+	clientOpts      []deps.ClientOption
+	cache           *deps.Cache
+	snapshot        *deps.Snapshot
+	versionInfo     deps.VersionInfoSource
+	skipVersionInfo bool
+	// includeWithdrawn counts yanked, retracted and deprecated releases like any other
+	includeWithdrawn bool
+	logger           *slog.Logger
+	maxWorkers       int
+
+	mu       sync.Mutex
+	failures map[FailureCategory]int
+	// missingModules holds the Go successor module PURLs the source does not know, so that
+	// they are probed once per calculator instead of once per component
+	missingModules map[string]bool
+}
+
+// CalculatorOption configures optional behaviour of a Calculator
+type CalculatorOption func(*Calculator)
+
+// WithCache makes the calculator serve version lookups from a persistent cache
+func WithCache(cache *deps.Cache) CalculatorOption {
+	return func(calc *Calculator) {
+		calc.cache = cache
+	}
+}
+
+// WithClientOptions passes options such as a retry policy to the deps.dev client
+func WithClientOptions(opts ...deps.ClientOption) CalculatorOption {
+	return func(calc *Calculator) {
+		calc.clientOpts = append(calc.clientOpts, opts...)
+	}
+}
+
+// WithSnapshot runs the calculator offline: all versions are read from the snapshot and
+// components missing from it fail instead of being looked up over the network
+func WithSnapshot(snapshot *deps.Snapshot) CalculatorOption {
+	return func(calc *Calculator) {
+		calc.snapshot = snapshot
+	}
+}
+
+// WithVersionSource replaces deps.dev with the given source, e.g. a chain that asks a
+// private registry first and falls back to deps.dev
+func WithVersionSource(source deps.VersionSource) CalculatorOption {
+	return func(calc *Calculator) {
+		calc.source = source
+	}
+}
+
+// WithRegistries adds registry backends that are asked before deps.dev. Packages they
+// do not support or do not know fall through to deps.dev. Packages whose PURL declares a
+// repository_url are resolved only by the backend serving that repository.
+func WithRegistries(registries ...deps.VersionSource) CalculatorOption {
+	return func(calc *Calculator) {
+		calc.registries = append(calc.registries, registries...)
+	}
+}
+
+// WithVersionInfo sets the source of deprecation, license and advisory details of the used
+// and newest versions. By default deps.dev is asked when it is the version source.
+func WithVersionInfo(source deps.VersionInfoSource) CalculatorOption {
+	return func(calc *Calculator) {
+		calc.versionInfo = source
+	}
+}
+
+// WithoutVersionInfo skips the lookup of version details, saving two requests per component
+func WithoutVersionInfo() CalculatorOption {
+	return func(calc *Calculator) {
+		calc.skipVersionInfo = true
+	}
+}
+
+// WithWithdrawnVersions counts yanked, retracted and deprecated releases as newest version and
+// as missed releases, as if they had not been withdrawn
+func WithWithdrawnVersions() CalculatorOption {
+	return func(calc *Calculator) {
+		calc.includeWithdrawn = true
+	}
 }
 
 // NewCalculator creates a new technical lag calculator
-func NewCalculator(logger *slog.Logger, maxWorkers int) *Calculator {
+func NewCalculator(logger *slog.Logger, maxWorkers int, opts ...CalculatorOption) *Calculator {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -35,11 +128,47 @@ func NewCalculator(logger *slog.Logger, maxWorkers int) *Calculator {
 		maxWorkers = 10 // Default number of concurrent workers
 	}
 
-	return &Calculator{
-		depsClient: deps.NewClient(logger),
+	calc := &Calculator{
 		logger:     logger,
 		maxWorkers: maxWorkers,
 	}
+
+	for _, opt := range opts {
+		opt(calc)
+	}
+
+	if calc.skipVersionInfo {
+		calc.versionInfo = nil
+	}
+
+	if calc.snapshot != nil {
+		calc.source = calc.snapshot
+		calc.versionInfo = nil
+		return calc
+	}
+	if calc.source != nil {
+		return calc
+	}
+
+	clientOpts := slices.Clone(calc.clientOpts)
+	if calc.cache != nil {
+		clientOpts = append(clientOpts, deps.WithCache(calc.cache))
+	}
+	calc.depsClient = deps.NewClient(logger, clientOpts...)
+	calc.source = calc.depsClient
+	if calc.versionInfo == nil && !calc.skipVersionInfo {
+		calc.versionInfo = calc.depsClient
+	}
+
+	if len(calc.registries) > 0 {
+		sources := append(slices.Clone(calc.registries), calc.source)
+		calc.source = deps.NewChainSource(logger, sources...)
+	}
+
+	// Packages declaring a private repository are only resolved by the registry serving it
+	calc.source = deps.NewRepositoryRouter(logger, calc.source, calc.registries...)
+
+	return calc
 }
 
 // componentJob represents a job for processing a component
@@ -67,7 +196,10 @@ func (calc *Calculator) Calculate(ctx context.Context, bom *cdx.BOM) (map[cdx.Co
 		return make(map[cdx.Component]TechnicalLag), nil
 	}
 
-	calc.logger.Info("Starting technical lag calculation", "components", len(components), "workers", calc.maxWorkers)
+	calc.logger.Info("Starting technical lag calculation",
+		"components", len(components),
+		"workers", calc.maxWorkers,
+		"source", calc.source.Name())
 
 	// Create channels for job distribution and result collection
 	jobs := make(chan componentJob, len(components))
@@ -100,32 +232,98 @@ func (calc *Calculator) Calculate(ctx context.Context, bom *cdx.BOM) (map[cdx.Co
 
 	// Collect results
 	componentToLag := make(map[cdx.Component]TechnicalLag, len(components))
+	failures := make(map[FailureCategory]int)
 	var errorCount int
 
 	for result := range results {
 		if result.err != nil {
+			category := ClassifyFailure(result.err)
 			calc.logger.Warn("Failed to calculate lag for component",
 				"component", result.component.Name,
 				"purl", result.component.PackageURL,
+				"category", category,
 				"error", result.err)
+			failures[category]++
 			errorCount++
 			continue
 		}
 		componentToLag[result.component] = result.lag
 	}
 
+	calc.mu.Lock()
+	calc.failures = failures
+	calc.mu.Unlock()
+
 	if errorCount > 0 {
-		calc.logger.Warn("Some components failed processing", "failed", errorCount, "total", len(components))
+		calc.logger.Warn("Some components failed processing", "failed", errorCount, "total", len(components), "by_category", failures)
 	}
 
+	calc.addVersionInfo(ctx, componentToLag)
+
 	calc.logger.Info("Technical lag calculation completed",
 		"processed", len(componentToLag),
 		"failed", errorCount,
 		"total", len(components))
 
+	if calc.cache != nil {
+		stats := calc.cache.Stats()
+		calc.logger.Info("Version cache usage", "hits", stats.Hits, "misses", stats.Misses)
+	}
+
+	if calc.depsClient != nil {
+		stats := calc.depsClient.Stats()
+		calc.logger.Info("deps.dev usage",
+			"requests", stats.Requests,
+			"retries", stats.Retries,
+			"retried_packages", stats.RetriedPackages,
+			"deduplicated", stats.Deduplicated,
+			"deduplicated_version_info", stats.DeduplicatedVersionInfo,
+			"batched_versions", stats.BatchedVersions)
+	}
+	for _, registry := range calc.registries {
+		if counted, ok := registry.(statsSource); ok {
+			stats := counted.Stats()
+			calc.logger.Info("Registry usage", "source", registry.Name(),
+				"requests", stats.Requests,
+				"retries", stats.Retries,
+				"retried_packages", stats.RetriedPackages)
+		}
+	}
+
 	return componentToLag, nil
 }
 
+// Failures returns the number of failed components per category of the last Calculate call
+func (calc *Calculator) Failures() map[FailureCategory]int {
+	calc.mu.Lock()
+	defer calc.mu.Unlock()
+	return maps.Clone(calc.failures)
+}
+
+// statsSource is implemented by sources counting their requests and retries, such as the
+// deps.dev client and container registries
+type statsSource interface {
+	Stats() deps.ClientStats
+}
+
+// AddRunStats adds the failures of the last Calculate call and the retries of the calculator
+// to summary, both of deps.dev and of registries that count them
+func (calc *Calculator) AddRunStats(summary *Summary) {
+	summary.Failures = calc.Failures()
+	summary.Retries, summary.RetriedPackages = 0, 0
+	sources := slices.Clone(calc.registries)
+	if calc.depsClient != nil {
+		sources = append(sources, calc.depsClient)
+	}
+	for _, source := range sources {
+		if counted, ok := source.(statsSource); ok {
+			stats := counted.Stats()
+			summary.Retries += stats.Retries
+			summary.RetriedPackages += stats.RetriedPackages
+		}
+	}
+}
+
 // worker processes component jobs concurrently
 func (calc *Calculator) worker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan componentJob, results chan<- componentResult) {
 	defer wg.Done()
@@ -152,55 +350,282 @@ func (calc *Calculator) worker(ctx context.Context, wg *sync.WaitGroup, jobs <-c
 // calculateComponentLag calculates technical lag for a single component
 func (calc *Calculator) calculateComponentLag(ctx context.Context, component cdx.Component) (TechnicalLag, error) {
 	if component.PackageURL == "" {
-		return TechnicalLag{}, fmt.Errorf("component %s has no package URL", component.Name)
+		return TechnicalLag{}, fmt.Errorf("%w: component %s has no package URL", deps.ErrInvalidPURL, component.Name)
 	}
 
 	if component.Version == "" {
-		return TechnicalLag{}, fmt.Errorf("component %s has no version", component.Name)
+		return TechnicalLag{}, fmt.Errorf("%w: %s", ErrMissingVersion, component.Name)
 	}
 
-	// Get versions from deps.dev API
-	depsResp, err := calc.depsClient.GetVersions(ctx, component.PackageURL)
+	// Get versions from the configured version source
+	depsResp, err := calc.source.GetVersions(ctx, component.PackageURL)
 	if err != nil {
 		return TechnicalLag{}, fmt.Errorf("failed to get versions for %s: %w", component.PackageURL, err)
 	}
 
 	if len(depsResp.Versions) == 0 {
-		return TechnicalLag{}, fmt.Errorf("no versions found for component %s", component.Name)
+		return TechnicalLag{}, fmt.Errorf("%w for component %s", semver.ErrNoVersionsProvided, component.Name)
 	}
 
 	// Convert API response to internal format
-	versions := make([]deps.Version, 0, len(depsResp.Versions))
-	rawVersions := make([]string, 0, len(depsResp.Versions))
+	versions := convertVersions(depsResp.Versions)
+	// Details are only looked up for versions of the component's own package
+	moduleVersions := versionStrings(versions)
+
+	// Versions are ordered by the rules of the package's ecosystem, e.g. dpkg for Debian packages.
+	// Withdrawn releases are neither the newest version nor missed, unless configured otherwise.
+	purlType, usedVersion := ecosystemVersion(component)
+
+	// Releases of Go modules with a higher major version are published under their own module path
+	rawVersions := moduleVersions
+	if purlType == packageurl.TypeGolang {
+		successors := calc.goMajorSuccessors(ctx, component.PackageURL, depsResp.Versions)
+		if len(successors) > 0 {
+			versions = appendSuccessorVersions(versions, successors)
+			rawVersions = versionStrings(versions)
+		}
+	}
+
+	semverOpts := []semver.Option{semver.WithScheme(semver.SchemeFor(purlType)), semver.WithVersionStatus(versions)}
+	if calc.includeWithdrawn {
+		semverOpts = append(semverOpts, semver.IncludeWithdrawn())
+	}
+
+	// Calculate libyear (time-based lag); sources without publication dates by design, such
+	// as Debian package indexes, only provide the version distance
+	var libdays float64
+	libdaysUnknown := depsResp.Metadata[deps.MetadataUndated] == "true"
+	if libdaysUnknown {
+		calc.logger.Debug("Source has no publication dates, libyear unknown", "purl", component.PackageURL)
+	} else {
+		libduration, err := semver.GetLibyear(usedVersion, versions, semverOpts...)
+		if err != nil {
+			return TechnicalLag{}, fmt.Errorf("failed to calculate libyear for %s: %w", component.Name, err)
+		}
+		libdays = libduration.Hours() / 24
+	}
+
+	// Calculate version distance (release-based lag)
+	versionDistance, err := semver.GetVersionDistance(usedVersion, rawVersions, semverOpts...)
+	if err != nil {
+		return TechnicalLag{}, fmt.Errorf("failed to calculate version distance for %s: %w", component.Name, err)
+	}
 
-	for _, v := range depsResp.Versions {
+	lag := TechnicalLag{
+		Libdays:         libdays,
+		LibdaysUnknown:  libdaysUnknown,
+		VersionDistance: *versionDistance,
+	}
+
+	if calc.versionInfo != nil && depsResp.Metadata[deps.MetadataSource] == calc.versionInfo.Name() {
+		if lag.newestVersion, err = semver.GetNewestVersion(moduleVersions, append(semverOpts, semver.ReleaseLineOf(usedVersion))...); err != nil {
+			lag.newestVersion = component.Version
+		}
+	}
+
+	return lag, nil
+}
+
+// convertVersions returns the versions of an API response, with the publication date and
+// deprecation taken from the outer structure where sources report them there
+func convertVersions(resp []deps.VersionsAPIResponse) []deps.Version {
+	versions := make([]deps.Version, 0, len(resp))
+	for _, v := range resp {
 		version := v.Version
-		// Handle cases where publication date is in the outer structure
 		if version.PublishedAt == "" && v.PublishedAt != "" {
 			version.PublishedAt = v.PublishedAt
 		}
+		version.Deprecated = version.Deprecated || v.IsDeprecated
 		versions = append(versions, version)
-		rawVersions = append(rawVersions, version.Version)
 	}
+	return versions
+}
 
-	// Calculate libyear (time-based lag)
-	libduration, err := semver.GetLibyear(component.Version, versions)
+// versionStrings returns the version strings of versions
+func versionStrings(versions []deps.Version) []string {
+	raw := make([]string, 0, len(versions))
+	for _, v := range versions {
+		raw = append(raw, v.Version)
+	}
+	return raw
+}
+
+// ecosystemVersion returns the PURL type of a component and its version as listed by version
+// sources of that type. RPM PURLs keep the epoch in a qualifier, while sources prefix it.
+// Images are identified by the version of their tag, often next to a digest as version.
+func ecosystemVersion(component cdx.Component) (string, string) {
+	purl, err := packageurl.FromString(component.PackageURL)
 	if err != nil {
-		return TechnicalLag{}, fmt.Errorf("failed to calculate libyear for %s: %w", component.Name, err)
+		return "", component.Version
 	}
 
-	libdays := libduration.Hours() / 24
+	switch purl.Type {
+	case packageurl.TypeRPM:
+		epoch := purl.Qualifiers.Map()["epoch"]
+		if epoch != "" && epoch != "0" && !strings.Contains(component.Version, ":") {
+			return purl.Type, epoch + ":" + component.Version
+		}
+	case packageurl.TypeOCI, packageurl.TypeDocker:
+		if _, _, tag, err := deps.ImageReference(purl); err == nil {
+			if version, _, ok := deps.SplitImageTag(tag); ok {
+				return purl.Type, version
+			}
+		}
+	}
+	return purl.Type, component.Version
+}
 
-	// Calculate version distance (release-based lag)
-	versionDistance, err := semver.GetVersionDistance(component.Version, rawVersions)
-	if err != nil {
-		return TechnicalLag{}, fmt.Errorf("failed to calculate version distance for %s: %w", component.Name, err)
+// addVersionInfo attaches details of the used and newest versions to all lags that asked for
+// them. All versions are prefetched at once if the source supports batches; failures are only
+// logged since the lags are complete without the details.
+func (calc *Calculator) addVersionInfo(ctx context.Context, lags map[cdx.Component]TechnicalLag) {
+	requests := make([]deps.VersionInfoRequest, 0, 2*len(lags))
+	seen := make(map[deps.VersionInfoRequest]struct{}, 2*len(lags))
+	for component, lag := range lags {
+		if lag.newestVersion == "" {
+			continue
+		}
+		for _, version := range []string{component.Version, lag.newestVersion} {
+			request := deps.VersionInfoRequest{PURL: component.PackageURL, Version: version}
+			if _, ok := seen[request]; !ok {
+				seen[request] = struct{}{}
+				requests = append(requests, request)
+			}
+		}
+	}
+	if len(requests) == 0 {
+		return
 	}
 
-	return TechnicalLag{
-		Libdays:         libdays,
-		VersionDistance: *versionDistance,
-	}, nil
+	if prefetcher, ok := calc.versionInfo.(deps.VersionInfoPrefetcher); ok {
+		if err := prefetcher.PrefetchVersionInfo(ctx, requests); err != nil {
+			calc.logger.Debug("Failed to prefetch version details", "versions", len(requests), "error", err)
+		}
+	}
+
+	// Prefetched versions are answered from memory, the rest with single requests
+	infos := make(map[deps.VersionInfoRequest]*deps.VersionInfo, len(requests))
+	queue := make(chan deps.VersionInfoRequest)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for range min(calc.maxWorkers, len(requests)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for request := range queue {
+				info, err := calc.versionInfo.GetVersionInfo(ctx, request.PURL, request.Version)
+				if err != nil {
+					calc.logger.Debug("Failed to get version details", "purl", request.PURL, "version", request.Version, "error", err)
+					continue
+				}
+				mu.Lock()
+				infos[request] = info
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, request := range requests {
+		select {
+		case queue <- request:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	for component, lag := range lags {
+		if lag.newestVersion == "" {
+			continue
+		}
+		lag.UsedVersion = infos[deps.VersionInfoRequest{PURL: component.PackageURL, Version: component.Version}]
+		lag.NewestVersion = infos[deps.VersionInfoRequest{PURL: component.PackageURL, Version: lag.newestVersion}]
+		lags[component] = lag
+	}
+}
+
+// ExportSnapshot looks up the versions of every component in the SBOM and collects them
+// into a snapshot that can later be used with WithSnapshot on a machine without network access
+func (calc *Calculator) ExportSnapshot(ctx context.Context, bom *cdx.BOM) (*deps.Snapshot, error) {
+	if bom.Components == nil {
+		return nil, fmt.Errorf("no components found in SBOM")
+	}
+
+	snapshot := deps.NewSnapshot()
+	purls := make(chan string)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errorCount int
+
+	for i := 0; i < calc.maxWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for purl := range purls {
+				resp, err := calc.source.GetVersions(ctx, purl)
+				if err == nil {
+					err = snapshot.Add(purl, resp)
+				}
+				if err != nil {
+					calc.logger.Warn("Failed to add component to snapshot", "purl", purl, "error", err)
+					mu.Lock()
+					errorCount++
+					mu.Unlock()
+					continue
+				}
+
+				// Successor module paths are needed to count missed Go major versions offline
+				if strings.HasPrefix(purl, "pkg:golang/") {
+					for _, successor := range calc.goMajorSuccessors(ctx, purl, resp.Versions) {
+						if err := snapshot.Add(successor.purl, successor.resp); err != nil {
+							calc.logger.Warn("Failed to add successor module to snapshot", "purl", successor.purl, "error", err)
+						}
+					}
+				}
+			}
+		}()
+	}
+
+	// Queue each package once; versions of the same package share a snapshot entry
+	queued := make(map[string]struct{})
+	for _, component := range *bom.Components {
+		if component.PackageURL == "" {
+			continue
+		}
+		key, err := deps.PackageKey(component.PackageURL)
+		if err != nil {
+			calc.logger.Warn("Skipping component for snapshot", "purl", component.PackageURL, "error", err)
+			continue
+		}
+		if _, ok := queued[key]; ok {
+			continue
+		}
+		queued[key] = struct{}{}
+
+		select {
+		case purls <- component.PackageURL:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(purls)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("snapshot export cancelled: %w", err)
+	}
+
+	calc.logger.Info("Versions snapshot created",
+		"packages", snapshot.Len(),
+		"failed", errorCount,
+		"components", len(*bom.Components))
+
+	return snapshot, nil
 }
 
 // Calculate provides a convenient function using the default calculator
@@ -216,7 +641,10 @@ type TechLagStats struct {
 	MissedMajor                    int64          `json:"missedMajor"`
 	MissedMinor                    int64          `json:"missedMinor"`
 	MissedPatch                    int64          `json:"missedPatch"`
+	MissedRevision                 int64          `json:"missedRevision,omitempty"`
+	MissedPackaging                int64          `json:"missedPackaging,omitempty"`
 	NumComponents                  int            `json:"numComponents"`
+	LibdaysUnknown                 int            `json:"libdaysUnknown,omitempty"`
 	HighestLibdays                 float64        `json:"highestLibdays"`
 	HighestMissedReleases          int64          `json:"highestMissedReleases"`
 	ComponentHighestMissedReleases cdx.Component  `json:"componentHighestMissedReleases"`
@@ -226,12 +654,18 @@ type TechLagStats struct {
 
 // ComponentLag represents technical lag for a single component
 type ComponentLag struct {
-	Component      cdx.Component `json:"component"`
-	Libdays        float64       `json:"libdays"`
-	MissedReleases int64         `json:"missedReleases"`
-	MissedMajor    int64         `json:"missedMajor"`
-	MissedMinor    int64         `json:"missedMinor"`
-	MissedPatch    int64         `json:"missedPatch"`
+	Component       cdx.Component `json:"component"`
+	Libdays         float64       `json:"libdays"`
+	LibdaysUnknown  bool          `json:"libdaysUnknown,omitempty"`
+	MissedReleases  int64         `json:"missedReleases"`
+	MissedMajor     int64         `json:"missedMajor"`
+	MissedMinor     int64         `json:"missedMinor"`
+	MissedPatch     int64         `json:"missedPatch"`
+	MissedRevision  int64         `json:"missedRevision,omitempty"`
+	MissedPackaging int64         `json:"missedPackaging,omitempty"`
+	// UsedVersion and NewestVersion hold deprecation, license and advisory details if available
+	UsedVersion   *deps.VersionInfo `json:"usedVersion,omitempty"`
+	NewestVersion *deps.VersionInfo `json:"newestVersion,omitempty"`
 }
 
 // Result contains comprehensive technical lag analysis results
@@ -249,8 +683,16 @@ type Summary struct {
 	TotalComponents    int     `json:"totalComponents"`
 	TotalLibdays       float64 `json:"totalLibdays"`
 	TotalMissedRelease int64   `json:"totalMissedReleases"`
-	AvgLibdays         float64 `json:"avgLibdays"`
-	AvgMissedReleases  float64 `json:"avgMissedReleases"`
+	// AvgLibdays is the average over the components with publication dates
+	AvgLibdays        float64 `json:"avgLibdays"`
+	AvgMissedReleases float64 `json:"avgMissedReleases"`
+	// LibdaysUnknown counts the components without publication dates
+	LibdaysUnknown int `json:"libdaysUnknown,omitempty"`
+	// Failures counts the components whose lag could not be calculated, by category
+	Failures map[FailureCategory]int `json:"failures,omitempty"`
+	// Retries counts the retried requests, RetriedPackages the packages that needed any
+	Retries         int64 `json:"retries"`
+	RetriedPackages int64 `json:"retriedPackages"`
 }
 
 // CreateResult generates a comprehensive result from component metrics
@@ -266,12 +708,17 @@ func CreateResult(bom *cdx.BOM, componentMetrics map[cdx.Component]TechnicalLag)
 	// Process all components
 	for component, lag := range componentMetrics {
 		componentLag := ComponentLag{
-			Component:      component,
-			Libdays:        lag.Libdays,
-			MissedReleases: lag.VersionDistance.MissedReleases,
-			MissedMajor:    lag.VersionDistance.MissedMajor,
-			MissedMinor:    lag.VersionDistance.MissedMinor,
-			MissedPatch:    lag.VersionDistance.MissedPatch,
+			Component:       component,
+			Libdays:         lag.Libdays,
+			LibdaysUnknown:  lag.LibdaysUnknown,
+			MissedReleases:  lag.VersionDistance.MissedReleases,
+			MissedMajor:     lag.VersionDistance.MissedMajor,
+			MissedMinor:     lag.VersionDistance.MissedMinor,
+			MissedPatch:     lag.VersionDistance.MissedPatch,
+			MissedRevision:  lag.VersionDistance.MissedRevision,
+			MissedPackaging: lag.VersionDistance.MissedPackaging,
+			UsedVersion:     lag.UsedVersion,
+			NewestVersion:   lag.NewestVersion,
 		}
 
 		if isProductionScope(component.Scope) {
@@ -289,12 +736,17 @@ func CreateResult(bom *cdx.BOM, componentMetrics map[cdx.Component]TechnicalLag)
 		for _, dep := range directDeps {
 			if lag, exists := componentMetrics[dep]; exists {
 				componentLag := ComponentLag{
-					Component:      dep,
-					Libdays:        lag.Libdays,
-					MissedReleases: lag.VersionDistance.MissedReleases,
-					MissedMajor:    lag.VersionDistance.MissedMajor,
-					MissedMinor:    lag.VersionDistance.MissedMinor,
-					MissedPatch:    lag.VersionDistance.MissedPatch,
+					Component:       dep,
+					Libdays:         lag.Libdays,
+					LibdaysUnknown:  lag.LibdaysUnknown,
+					MissedReleases:  lag.VersionDistance.MissedReleases,
+					MissedMajor:     lag.VersionDistance.MissedMajor,
+					MissedMinor:     lag.VersionDistance.MissedMinor,
+					MissedPatch:     lag.VersionDistance.MissedPatch,
+					MissedRevision:  lag.VersionDistance.MissedRevision,
+					MissedPackaging: lag.VersionDistance.MissedPackaging,
+					UsedVersion:     lag.UsedVersion,
+					NewestVersion:   lag.NewestVersion,
 				}
 
 				if isProductionScope(dep.Scope) {
@@ -319,11 +771,17 @@ func isProductionScope(scope cdx.Scope) bool {
 
 // updateTechLagStats updates aggregate statistics with component data
 func updateTechLagStats(stats *TechLagStats, lag TechnicalLag, component cdx.Component, componentLag ComponentLag) {
-	stats.Libdays += lag.Libdays
+	if lag.LibdaysUnknown {
+		stats.LibdaysUnknown++
+	} else {
+		stats.Libdays += lag.Libdays
+	}
 	stats.MissedReleases += lag.VersionDistance.MissedReleases
 	stats.MissedMajor += lag.VersionDistance.MissedMajor
 	stats.MissedMinor += lag.VersionDistance.MissedMinor
 	stats.MissedPatch += lag.VersionDistance.MissedPatch
+	stats.MissedRevision += lag.VersionDistance.MissedRevision
+	stats.MissedPackaging += lag.VersionDistance.MissedPackaging
 	stats.NumComponents++
 	stats.Components = append(stats.Components, componentLag)
 
@@ -331,7 +789,7 @@ func updateTechLagStats(stats *TechLagStats, lag TechnicalLag, component cdx.Com
 		stats.HighestMissedReleases = lag.VersionDistance.MissedReleases
 		stats.ComponentHighestMissedReleases = component
 	}
-	if lag.Libdays > stats.HighestLibdays {
+	if !lag.LibdaysUnknown && lag.Libdays > stats.HighestLibdays {
 		stats.HighestLibdays = lag.Libdays
 		stats.ComponentHighestLibdays = component
 	}
@@ -342,12 +800,15 @@ func calculateSummary(result Result) Summary {
 	totalComponents := result.Production.NumComponents + result.Optional.NumComponents
 	totalLibdays := result.Production.Libdays + result.Optional.Libdays
 	totalMissedReleases := result.Production.MissedReleases + result.Optional.MissedReleases
+	libdaysUnknown := result.Production.LibdaysUnknown + result.Optional.LibdaysUnknown
 
 	var avgLibdays, avgMissedReleases float64
 	if totalComponents > 0 {
-		avgLibdays = totalLibdays / float64(totalComponents)
 		avgMissedReleases = float64(totalMissedReleases) / float64(totalComponents)
 	}
+	if dated := totalComponents - libdaysUnknown; dated > 0 {
+		avgLibdays = totalLibdays / float64(dated)
+	}
 
 	return Summary{
 		TotalComponents:    totalComponents,
@@ -355,6 +816,7 @@ func calculateSummary(result Result) Summary {
 		TotalMissedRelease: totalMissedReleases,
 		AvgLibdays:         avgLibdays,
 		AvgMissedReleases:  avgMissedReleases,
+		LibdaysUnknown:     libdaysUnknown,
 	}
 }
 
@@ -365,7 +827,7 @@ func (r *Result) String() string {
 		floatFormat = "%-25s prod: %-10.2f opt: %-10.2f direct prod: %-10.2f direct opt: %.2f\n"
 	)
 
-	return fmt.Sprintf(
+	summary := fmt.Sprintf(
 		"=== Technical Lag Analysis ===\n"+
 			intFormat+ // NumComponents
 			floatFormat+ // Libdays
@@ -373,10 +835,13 @@ func (r *Result) String() string {
 			intFormat+ // MissedMajor
 			intFormat+ // MissedMinor
 			intFormat+ // MissedPatch
+			intFormat+ // MissedRevision
+			intFormat+ // MissedPackaging
 			"\n=== Summary ===\n"+
 			"Total components: %d\n"+
 			"Total libdays: %.2f\n"+
 			"Total missed releases: %d\n"+
+			"Components without libdays: %d\n"+
 			"Average libdays per component: %.2f\n"+
 			"Average missed releases per component: %.2f\n",
 
@@ -387,12 +852,30 @@ func (r *Result) String() string {
 		"Missed major", r.Production.MissedMajor, r.Optional.MissedMajor, r.DirectProduction.MissedMajor, r.DirectOptional.MissedMajor,
 		"Missed minor", r.Production.MissedMinor, r.Optional.MissedMinor, r.DirectProduction.MissedMinor, r.DirectOptional.MissedMinor,
 		"Missed patch", r.Production.MissedPatch, r.Optional.MissedPatch, r.DirectProduction.MissedPatch, r.DirectOptional.MissedPatch,
+		"Missed revision", r.Production.MissedRevision, r.Optional.MissedRevision, r.DirectProduction.MissedRevision, r.DirectOptional.MissedRevision,
+		"Missed packaging", r.Production.MissedPackaging, r.Optional.MissedPackaging, r.DirectProduction.MissedPackaging, r.DirectOptional.MissedPackaging,
 
 		// Summary
 		r.Summary.TotalComponents,
 		r.Summary.TotalLibdays,
 		r.Summary.TotalMissedRelease,
+		r.Summary.LibdaysUnknown,
 		r.Summary.AvgLibdays,
 		r.Summary.AvgMissedReleases,
 	)
+
+	if r.Summary.Retries > 0 {
+		summary += fmt.Sprintf("Retried requests: %d (%d packages)\n", r.Summary.Retries, r.Summary.RetriedPackages)
+	}
+	if len(r.Summary.Failures) == 0 {
+		return summary
+	}
+
+	var failed int
+	categories := make([]string, 0, len(r.Summary.Failures))
+	for _, category := range slices.Sorted(maps.Keys(r.Summary.Failures)) {
+		failed += r.Summary.Failures[category]
+		categories = append(categories, fmt.Sprintf("%s: %d", category, r.Summary.Failures[category]))
+	}
+	return summary + fmt.Sprintf("Failed components: %d (%s)\n", failed, strings.Join(categories, ", "))
 }
@@ -1,9 +1,20 @@
 package technicalLag
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sbom-technical-lag/internal/deps"
 	"sbom-technical-lag/internal/semver"
 	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 )
@@ -578,3 +589,451 @@ func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr ||
 		(len(s) > len(substr) && contains(s[1:], substr))
 }
+
+func TestSummaryCountsRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"versions":[
+			{"versionKey":{"version":"3.5.17"},"publishedAt":"2025-06-18T10:00:00Z"},
+			{"versionKey":{"version":"3.5.18"},"publishedAt":"2025-07-23T10:00:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	calc := NewCalculator(nil, 1, WithoutVersionInfo(), WithClientOptions(
+		deps.WithBaseURL(server.URL),
+		deps.WithRetryPolicy(deps.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+	))
+	bom := &cdx.BOM{Components: &[]cdx.Component{{Name: "vue", Version: "3.5.17", PackageURL: "pkg:npm/vue@3.5.17"}}}
+	metrics, err := calc.Calculate(context.Background(), bom)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	result, err := CreateResult(bom, metrics)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	calc.AddRunStats(&result.Summary)
+
+	if result.Summary.TotalComponents != 1 || result.Summary.Retries != 1 || result.Summary.RetriedPackages != 1 {
+		t.Errorf("expected the rate-limited request to be counted as retry, got %+v", result.Summary)
+	}
+	if !strings.Contains(result.String(), "Retried requests: 1 (1 packages)") {
+		t.Errorf("expected retries in the summary text, got:\n%s", result.String())
+	}
+}
+
+func TestCalculateOffline(t *testing.T) {
+	snapshot := deps.NewSnapshot()
+	err := snapshot.Add("pkg:npm/vue@3.5.17", &deps.APIResponse{
+		Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "3.5.17"}, PublishedAt: "2025-06-18T10:00:00Z"},
+			{Version: deps.Version{Version: "3.5.18"}, PublishedAt: "2025-07-23T10:00:00Z"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to build snapshot: %v", err)
+	}
+
+	known := cdx.Component{Name: "vue", Version: "3.5.17", PackageURL: "pkg:npm/vue@3.5.17"}
+	missing := cdx.Component{Name: "vite", Version: "7.0.0", PackageURL: "pkg:npm/vite@7.0.0"}
+	bom := &cdx.BOM{Components: &[]cdx.Component{known, missing}}
+
+	calc := NewCalculator(nil, 2, WithSnapshot(snapshot))
+	metrics, err := calc.Calculate(context.Background(), bom)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	if len(metrics) != 1 {
+		t.Fatalf("expected only the snapshot component to succeed, got %d results", len(metrics))
+	}
+	if lag := metrics[known]; lag.VersionDistance.MissedPatch != 1 || lag.Libdays != 35 {
+		t.Errorf("unexpected lag for %s: %+v", known.Name, lag)
+	}
+
+	if _, err := calc.calculateComponentLag(context.Background(), missing); !errors.Is(err, deps.ErrNotInSnapshot) {
+		t.Errorf("expected ErrNotInSnapshot for missing component, got: %v", err)
+	}
+}
+
+func TestExportSnapshotForRegistryEcosystems(t *testing.T) {
+	// Composer, Hex, pub, CocoaPods and Swift packages are unknown to deps.dev
+	source := fakeSource{byPURL: map[string]*deps.APIResponse{
+		"pkg:composer/monolog/monolog": {Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "3.5.0", PublishedAt: "2023-10-27T00:00:00Z"}},
+			{Version: deps.Version{Version: "3.6.0", PublishedAt: "2024-04-12T00:00:00Z"}},
+		}},
+		"pkg:hex/jason": {Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "1.4.0", PublishedAt: "2022-09-05T00:00:00Z"}},
+			{Version: deps.Version{Version: "1.4.1", PublishedAt: "2023-07-24T00:00:00Z"}},
+		}},
+	}}
+	components := []cdx.Component{
+		{Name: "monolog", Version: "3.5.0", PackageURL: "pkg:composer/monolog/monolog@3.5.0"},
+		{Name: "jason", Version: "1.4.0", PackageURL: "pkg:hex/jason@1.4.0"},
+	}
+
+	exported, err := NewCalculator(nil, 1, WithVersionSource(source)).ExportSnapshot(context.Background(), &cdx.BOM{Components: &components})
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := exported.Save(path); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+	snapshot, err := deps.LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+
+	calc := NewCalculator(nil, 1, WithSnapshot(snapshot))
+	for _, component := range components {
+		lag, err := calc.calculateComponentLag(context.Background(), component)
+		if err != nil {
+			t.Fatalf("%s: expected the component in the snapshot, got: %v", component.Name, err)
+		}
+		if lag.VersionDistance.MissedReleases != 1 || lag.Libdays == 0 {
+			t.Errorf("%s: expected lag behind the newer release, got %+v", component.Name, lag)
+		}
+	}
+}
+
+// fakeSource is a deps.VersionSource serving the same versions for every package, or with
+// byPURL the versions of each versionless PURL and ErrPackageNotFound for other packages
+type fakeSource struct {
+	resp   *deps.APIResponse
+	byPURL map[string]*deps.APIResponse
+}
+
+func (f fakeSource) Name() string { return "fake" }
+
+func (f fakeSource) GetVersions(_ context.Context, rawPURL string) (*deps.APIResponse, error) {
+	if f.byPURL == nil {
+		return f.resp, nil
+	}
+	modulePURL, _, _ := strings.Cut(rawPURL, "@")
+	if resp, ok := f.byPURL[modulePURL]; ok {
+		return resp, nil
+	}
+	return nil, fmt.Errorf("%w: %s", deps.ErrPackageNotFound, rawPURL)
+}
+
+func TestCalculateWithVersionSource(t *testing.T) {
+	source := fakeSource{resp: &deps.APIResponse{
+		Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "1.0.0", PublishedAt: "2021-01-01T00:00:00Z"}},
+			{Version: deps.Version{Version: "2.0.0", PublishedAt: "2021-01-11T00:00:00Z"}},
+		},
+	}}
+
+	component := cdx.Component{Name: "internal-lib", Version: "1.0.0", PackageURL: "pkg:npm/%40acme/internal-lib@1.0.0"}
+	bom := &cdx.BOM{Components: &[]cdx.Component{component}}
+
+	metrics, err := NewCalculator(nil, 1, WithVersionSource(source)).Calculate(context.Background(), bom)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	lag, ok := metrics[component]
+	if !ok {
+		t.Fatalf("expected result for %s", component.Name)
+	}
+	if lag.Libdays != 10 || lag.VersionDistance.MissedMajor != 1 {
+		t.Errorf("unexpected lag: %+v", lag)
+	}
+}
+
+// fakeVersionInfo is a deps.VersionInfoSource marking every version as deprecated
+type fakeVersionInfo struct {
+	name string
+}
+
+func (f fakeVersionInfo) Name() string { return f.name }
+
+func (f fakeVersionInfo) GetVersionInfo(_ context.Context, _ string, version string) (*deps.VersionInfo, error) {
+	return &deps.VersionInfo{Version: version, IsDeprecated: true, Licenses: []string{"MIT"}}, nil
+}
+
+func TestCalculateAttachesVersionInfo(t *testing.T) {
+	source := fakeSource{resp: &deps.APIResponse{
+		Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "1.0.0", PublishedAt: "2021-01-01T00:00:00Z"}},
+			{Version: deps.Version{Version: "2.0.0", PublishedAt: "2021-01-11T00:00:00Z"}},
+		},
+		Metadata: map[string]string{deps.MetadataSource: "fake"},
+	}}
+	component := cdx.Component{Name: "lib", Version: "1.0.0", PackageURL: "pkg:npm/lib@1.0.0"}
+	bom := &cdx.BOM{Components: &[]cdx.Component{component}}
+
+	metrics, err := NewCalculator(nil, 1, WithVersionSource(source), WithVersionInfo(fakeVersionInfo{name: "fake"})).
+		Calculate(context.Background(), bom)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	lag := metrics[component]
+	if lag.UsedVersion == nil || lag.UsedVersion.Version != "1.0.0" || !lag.UsedVersion.IsDeprecated {
+		t.Errorf("unexpected used version info: %+v", lag.UsedVersion)
+	}
+	if lag.NewestVersion == nil || lag.NewestVersion.Version != "2.0.0" {
+		t.Errorf("unexpected newest version info: %+v", lag.NewestVersion)
+	}
+
+	result, err := CreateResult(bom, metrics)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if got := result.Production.Components[0]; got.UsedVersion != lag.UsedVersion || got.NewestVersion != lag.NewestVersion {
+		t.Errorf("version info not attached to component lag: %+v", got)
+	}
+
+	// Versions served by another source are never looked up
+	metrics, err = NewCalculator(nil, 1, WithVersionSource(source), WithVersionInfo(fakeVersionInfo{name: "deps.dev"})).
+		Calculate(context.Background(), bom)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag := metrics[component]; lag.UsedVersion != nil || lag.NewestVersion != nil {
+		t.Errorf("expected no version info for foreign source, got %+v", lag)
+	}
+}
+
+// prefetchingVersionInfo records the versions prefetched in one batch
+type prefetchingVersionInfo struct {
+	fakeVersionInfo
+	prefetched []deps.VersionInfoRequest
+}
+
+func (p *prefetchingVersionInfo) PrefetchVersionInfo(_ context.Context, requests []deps.VersionInfoRequest) error {
+	p.prefetched = append(p.prefetched, requests...)
+	return nil
+}
+
+func TestCalculatePrefetchesVersionInfo(t *testing.T) {
+	source := fakeSource{resp: &deps.APIResponse{
+		Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "1.0.0", PublishedAt: "2021-01-01T00:00:00Z"}},
+			{Version: deps.Version{Version: "2.0.0", PublishedAt: "2021-01-11T00:00:00Z"}},
+		},
+		Metadata: map[string]string{deps.MetadataSource: "fake"},
+	}}
+	bom := &cdx.BOM{Components: &[]cdx.Component{
+		{Name: "a", Version: "1.0.0", PackageURL: "pkg:npm/a@1.0.0"},
+		{Name: "b", Version: "2.0.0", PackageURL: "pkg:npm/b@2.0.0"},
+	}}
+
+	info := &prefetchingVersionInfo{fakeVersionInfo: fakeVersionInfo{name: "fake"}}
+	metrics, err := NewCalculator(nil, 2, WithVersionSource(source), WithVersionInfo(info)).Calculate(context.Background(), bom)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+
+	// a needs its used and newest version, b is up to date and needs one
+	if len(info.prefetched) != 3 {
+		t.Errorf("expected 3 versions in one prefetch, got %v", info.prefetched)
+	}
+	for component, lag := range metrics {
+		if lag.UsedVersion == nil || lag.NewestVersion == nil || lag.NewestVersion.Version != "2.0.0" {
+			t.Errorf("missing version details for %s: %+v", component.Name, lag)
+		}
+	}
+}
+
+func TestCalculateRejectsUnknownRepository(t *testing.T) {
+	calc := NewCalculator(nil, 1, WithClientOptions(deps.WithBaseURL("http://127.0.0.1:0")))
+	component := cdx.Component{
+		Name:       "lib",
+		Version:    "1.0.0",
+		PackageURL: "pkg:maven/com.acme/lib@1.0.0?repository_url=https://nexus.acme.local/repository/releases",
+	}
+
+	if _, err := calc.calculateComponentLag(context.Background(), component); !errors.Is(err, deps.ErrUnknownRepository) {
+		t.Errorf("expected ErrUnknownRepository, got: %v", err)
+	}
+}
+
+func TestCalculateOSPackages(t *testing.T) {
+	// Debian indexes have no publication dates, so only the version distance is known
+	deb := fakeSource{resp: &deps.APIResponse{
+		Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "2.36-9+deb12u10"}},
+			{Version: deps.Version{Version: "2.36-9+deb12u4"}},
+			{Version: deps.Version{Version: "2.36-9"}},
+		},
+		Metadata: map[string]string{deps.MetadataUndated: "true"},
+	}}
+	component := cdx.Component{Name: "libc6", Version: "2.36-9+deb12u4", PackageURL: "pkg:deb/debian/libc6@2.36-9%2Bdeb12u4?distro=debian-12"}
+
+	lag, err := NewCalculator(nil, 1, WithVersionSource(deb)).calculateComponentLag(context.Background(), component)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag.Libdays != 0 || !lag.LibdaysUnknown || lag.VersionDistance.MissedReleases != 1 {
+		t.Errorf("unexpected lag for %s: %+v", component.Name, lag)
+	}
+
+	// Other sources that lose their dates fail instead of reporting no lag
+	undated := fakeSource{resp: &deps.APIResponse{Versions: deb.resp.Versions}}
+	if _, err := NewCalculator(nil, 1, WithVersionSource(undated)).calculateComponentLag(context.Background(), component); err == nil {
+		t.Error("expected error for versions without dates from a source with dates")
+	}
+
+	// RPM PURLs carry the epoch as qualifier, sources as version prefix
+	rpm := fakeSource{resp: &deps.APIResponse{
+		Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "1:3.0.7-27.el9", PublishedAt: "2024-01-01T00:00:00Z"}},
+			{Version: deps.Version{Version: "1:3.0.7-28.el9", PublishedAt: "2024-01-21T00:00:00Z"}},
+		},
+	}}
+	component = cdx.Component{Name: "openssl", Version: "3.0.7-27.el9", PackageURL: "pkg:rpm/redhat/openssl@3.0.7-27.el9?epoch=1&distro=rhel-9"}
+
+	lag, err = NewCalculator(nil, 1, WithVersionSource(rpm)).calculateComponentLag(context.Background(), component)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag.Libdays != 20 || lag.VersionDistance.MissedReleases != 1 {
+		t.Errorf("unexpected lag for %s: %+v", component.Name, lag)
+	}
+}
+
+func TestCalculateImageTag(t *testing.T) {
+	source := fakeSource{resp: &deps.APIResponse{
+		Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "1.25.3", PublishedAt: "2023-10-24T00:00:00Z"}},
+			{Version: deps.Version{Version: "1.26.0", PublishedAt: "2024-02-14T00:00:00Z"}},
+		},
+	}}
+	component := cdx.Component{
+		Name:       "nginx",
+		Version:    "sha256:4c0fdaa8b6341bfdeca5f18f7837462c80cff90527ee35ef185571e1c327beac",
+		PackageURL: "pkg:oci/nginx@sha256%3A4c0fdaa8b6341bfdeca5f18f7837462c80cff90527ee35ef185571e1c327beac?tag=1.25.3-alpine",
+	}
+
+	lag, err := NewCalculator(nil, 1, WithVersionSource(source)).calculateComponentLag(context.Background(), component)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag.Libdays != 113 || lag.VersionDistance.MissedMinor != 1 {
+		t.Errorf("unexpected lag for %s: %+v", component.Name, lag)
+	}
+}
+
+func TestCalculateWithdrawnVersions(t *testing.T) {
+	source := fakeSource{byPURL: map[string]*deps.APIResponse{
+		"pkg:golang/example.com/lib": {
+			Versions: []deps.VersionsAPIResponse{
+				{Version: deps.Version{Version: "1.0.0", PublishedAt: "2021-01-01T00:00:00Z"}},
+				{Version: deps.Version{Version: "1.1.0", PublishedAt: "2021-01-11T00:00:00Z"}},
+				{Version: deps.Version{Version: "1.2.0", PublishedAt: "2021-01-21T00:00:00Z", Retracted: true}},
+				{Version: deps.Version{Version: "2.0.0", PublishedAt: "2021-01-31T00:00:00Z"}, IsDeprecated: true},
+			},
+		},
+	}}
+	component := cdx.Component{Name: "lib", Version: "1.0.0", PackageURL: "pkg:golang/example.com/lib@1.0.0"}
+
+	lag, err := NewCalculator(nil, 1, WithVersionSource(source)).calculateComponentLag(context.Background(), component)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag.Libdays != 10 || lag.VersionDistance != (semver.VersionDistance{MissedReleases: 1, MissedMinor: 1}) {
+		t.Errorf("expected withdrawn releases to be skipped, got %+v", lag)
+	}
+
+	lag, err = NewCalculator(nil, 1, WithVersionSource(source), WithWithdrawnVersions()).calculateComponentLag(context.Background(), component)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if lag.Libdays != 30 || lag.VersionDistance.MissedReleases != 3 || lag.VersionDistance.MissedMajor != 1 {
+		t.Errorf("expected withdrawn releases to count, got %+v", lag)
+	}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want FailureCategory
+	}{
+		{fmt.Errorf("deps.dev: %w (retry after: 60)", deps.ErrRateLimited), FailureRateLimited},
+		{fmt.Errorf("%w \"pkg:\": missing type", deps.ErrInvalidPURL), FailureInvalidPURL},
+		{fmt.Errorf("%w: lib", ErrMissingVersion), FailureMissingVersion},
+		{fmt.Errorf("failed to calculate libyear: %w", semver.ErrVersionNotFound), FailureUsedVersionUnknown},
+		{fmt.Errorf("invalid used version: %w", semver.ErrInvalidVersion), FailureUsedVersionUnknown},
+		{fmt.Errorf("failed to parse available versions: %w", semver.ErrNoValidVersions), FailureNoValidVersions},
+		{errors.Join(
+			fmt.Errorf("pypi: %w: npm", deps.ErrUnsupportedPackageType),
+			fmt.Errorf("deps.dev: %w", deps.ErrPackageNotFound),
+		), FailureNotFound},
+		{fmt.Errorf("%w: registry.acme.local", deps.ErrUnknownRepository), FailureUnknownRepository},
+		{fmt.Errorf("%w: pkg:hackage/lens", deps.ErrUnsupportedPackageType), FailureUnsupportedEcosystem},
+		{errors.Join(
+			fmt.Errorf("deps.dev: %w: golang", deps.ErrUnsupportedPackageType),
+			fmt.Errorf("goproxy: %w: git.acme.local/lib", deps.ErrDirectModuleFetch),
+		), FailureDirectFetch},
+		{fmt.Errorf("lookup: %w", context.Canceled), FailureCancelled},
+		{errors.New("HTTP 500"), FailureOther},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyFailure(tt.err); got != tt.want {
+			t.Errorf("%v: expected %s, got %s", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestCalculateRecordsFailures(t *testing.T) {
+	source := fakeSource{resp: &deps.APIResponse{
+		Versions: []deps.VersionsAPIResponse{
+			{Version: deps.Version{Version: "1.0.0", PublishedAt: "2021-01-01T00:00:00Z"}},
+		},
+	}}
+	bom := &cdx.BOM{Components: &[]cdx.Component{
+		{Name: "ok", Version: "1.0.0", PackageURL: "pkg:npm/ok@1.0.0"},
+		{Name: "unreleased", Version: "2.0.0", PackageURL: "pkg:npm/unreleased@2.0.0"},
+		{Name: "unversioned", PackageURL: "pkg:npm/unversioned"},
+		{Name: "no-purl", Version: "1.0.0"},
+	}}
+
+	calc := NewCalculator(nil, 2, WithVersionSource(source))
+	metrics, err := calc.Calculate(context.Background(), bom)
+	if err != nil {
+		t.Fatalf("no error expected, got: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(metrics))
+	}
+
+	want := map[FailureCategory]int{FailureMissingVersion: 1, FailureInvalidPURL: 1, FailureUsedVersionUnknown: 1}
+	if failures := calc.Failures(); !maps.Equal(failures, want) {
+		t.Errorf("expected failures %v, got %v", want, failures)
+	}
+
+	result := Result{Summary: Summary{Failures: want}}
+	if s := result.String(); !strings.Contains(s, "Failed components: 3 (invalidPurl: 1, missingVersion: 1, usedVersionUnknown: 1)") {
+		t.Errorf("expected failure breakdown in summary, got:\n%s", s)
+	}
+}
+
+func TestUnknownLibdaysLeftOutOfAverages(t *testing.T) {
+	dated := cdx.Component{Name: "dated", Version: "1.0.0"}
+	undated := cdx.Component{Name: "undated", Version: "2.36-9"}
+
+	var stats TechLagStats
+	updateTechLagStats(&stats, TechnicalLag{Libdays: 10, VersionDistance: semver.VersionDistance{MissedReleases: 1}}, dated, ComponentLag{Component: dated})
+	updateTechLagStats(&stats, TechnicalLag{LibdaysUnknown: true, VersionDistance: semver.VersionDistance{MissedReleases: 3}}, undated, ComponentLag{Component: undated})
+
+	if stats.Libdays != 10 || stats.HighestLibdays != 10 || stats.ComponentHighestLibdays.Name != "dated" || stats.LibdaysUnknown != 1 {
+		t.Errorf("expected only the dated component in the libyear statistics, got %+v", stats)
+	}
+
+	summary := calculateSummary(Result{Production: stats})
+	if summary.AvgLibdays != 10 || summary.LibdaysUnknown != 1 {
+		t.Errorf("expected average libdays of the dated component, got %+v", summary)
+	}
+	if summary.AvgMissedReleases != 2 {
+		t.Errorf("expected missed releases averaged over all components, got %v", summary.AvgMissedReleases)
+	}
+}